@@ -0,0 +1,132 @@
+// Package baseline records the fingerprints of known findings so that only
+// new findings cause a diff check to fail.
+//
+// A fingerprint is made from the rule that was triggered, the path of the file
+// and a hash of the text that was matched. It doesn't include the line number,
+// so a known finding stays known when lines are added above it.
+package baseline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+)
+
+// DefaultFile is the name of the baseline file at the top of a repository
+const DefaultFile = ".diffcheck-baseline.json"
+
+// Version of the baseline file format
+const Version = 1
+
+// Entry is a single known finding
+type Entry struct {
+	Fingerprint string `json:"fingerprint"`
+
+	// Rule and Path are recorded alongside the fingerprint so that the file
+	// can be reviewed by a person
+	Rule string `json:"rule"`
+	Path string `json:"path"`
+
+	// Line the finding was on when it was recorded. This is for information
+	// only and isn't used when matching.
+	Line int `json:"line,omitempty"`
+}
+
+// Baseline is a set of known findings
+type Baseline struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+
+	index map[string]bool
+}
+
+// New returns an empty Baseline
+func New() *Baseline {
+	return &Baseline{Version: Version, Entries: []Entry{}, index: map[string]bool{}}
+}
+
+// Fingerprint returns the fingerprint for a match of the rule in the file at
+// path
+func Fingerprint(rule, path string, match []byte) string {
+	m := sha256.Sum256(match)
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%x", rule, path, m)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Read loads the baseline file at path
+func Read(path string) (*Baseline, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	bl := New()
+	if err := json.Unmarshal(b, bl); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if bl.Version != Version {
+		return nil, fmt.Errorf("%s: unsupported baseline version %d", path, bl.Version)
+	}
+
+	for _, e := range bl.Entries {
+		bl.index[e.Fingerprint] = true
+	}
+	return bl, nil
+}
+
+// Write saves the baseline to path. Entries are sorted so that the file is
+// stable between runs.
+func (b *Baseline) Write(path string) error {
+	sort.Slice(b.Entries, func(i, j int) bool {
+		x, y := b.Entries[i], b.Entries[j]
+		if x.Path != y.Path {
+			return x.Path < y.Path
+		}
+		if x.Line != y.Line {
+			return x.Line < y.Line
+		}
+		return x.Fingerprint < y.Fingerprint
+	})
+
+	out, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(out, '\n'), 0644)
+}
+
+// Add records a finding. Findings already in the baseline are ignored.
+func (b *Baseline) Add(e Entry) {
+	if b.index[e.Fingerprint] {
+		return
+	}
+	b.index[e.Fingerprint] = true
+	b.Entries = append(b.Entries, e)
+}
+
+// Contains reports whether the finding with the given fingerprint is known. A
+// nil Baseline contains nothing.
+func (b *Baseline) Contains(fingerprint string) bool {
+	return b != nil && b.index[fingerprint]
+}
+
+// Prune removes any entries that aren't also in current, returning the removed
+// entries
+func (b *Baseline) Prune(current *Baseline) []Entry {
+	kept := []Entry{}
+	removed := []Entry{}
+	for _, e := range b.Entries {
+		if current.Contains(e.Fingerprint) {
+			kept = append(kept, e)
+		} else {
+			removed = append(removed, e)
+			delete(b.index, e.Fingerprint)
+		}
+	}
+	b.Entries = kept
+	return removed
+}
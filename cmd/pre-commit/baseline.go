@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+
+	"github.com/ONSdigital/git-diff-check/baseline"
+	"github.com/ONSdigital/git-diff-check/diffcheck"
+	"github.com/ONSdigital/git-diff-check/gitutil"
+)
+
+// runBaseline scans every file in the current HEAD commit and adds all of the
+// findings to the repository's baseline file, so that they no longer cause
+// commits to be rejected
+func runBaseline(args []string) int {
+	fs := flag.NewFlagSet("baseline", flag.ExitOnError)
+	target := fs.String("p", ".", "(optional) path to repository")
+	var rulePaths listFlag
+	fs.Var(&rulePaths, "rules", "(optional) JSON rule file or directory of rule files to load. May be repeated")
+	fs.Parse(args)
+
+	cfg := configure(*target, rulePaths)
+	path := filepath.Join(cfg.Root, baseline.DefaultFile)
+
+	bl := diffcheck.Baseline
+	if bl == nil {
+		bl = baseline.New()
+	}
+	before := len(bl.Entries)
+
+	current, err := scanTree(cfg.Root)
+	if err != nil {
+		log.Fatal("Failed to scan tree:", err)
+	}
+	for _, e := range current.Entries {
+		bl.Add(e)
+	}
+
+	if err := bl.Write(path); err != nil {
+		log.Fatal("Failed to write baseline:", err)
+	}
+	fmt.Printf("Recorded %d findings (%d new) in %s\n", len(bl.Entries), len(bl.Entries)-before, path)
+	return accepted
+}
+
+// runPruneBaseline removes entries from the repository's baseline file that
+// are no longer found in the current HEAD commit
+func runPruneBaseline(args []string) int {
+	fs := flag.NewFlagSet("prune-baseline", flag.ExitOnError)
+	target := fs.String("p", ".", "(optional) path to repository")
+	var rulePaths listFlag
+	fs.Var(&rulePaths, "rules", "(optional) JSON rule file or directory of rule files to load. May be repeated")
+	fs.Parse(args)
+
+	cfg := configure(*target, rulePaths)
+	path := filepath.Join(cfg.Root, baseline.DefaultFile)
+
+	bl := diffcheck.Baseline
+	if bl == nil {
+		fmt.Printf("No baseline found at %s\n", path)
+		return rejected
+	}
+
+	current, err := scanTree(cfg.Root)
+	if err != nil {
+		log.Fatal("Failed to scan tree:", err)
+	}
+
+	removed := bl.Prune(current)
+	for _, e := range removed {
+		fmt.Printf("Removed %s (%s)\n", e.Rule, e.Path)
+	}
+
+	if err := bl.Write(path); err != nil {
+		log.Fatal("Failed to write baseline:", err)
+	}
+	fmt.Printf("Removed %d findings, %d remain in %s\n", len(removed), len(bl.Entries), path)
+	return accepted
+}
+
+// scanTree checks every file in the HEAD commit of the repository at root and
+// returns all findings as a baseline
+func scanTree(root string) (*baseline.Baseline, error) {
+	// Diff against the empty tree so that every line of every file is seen
+	// as added
+	empty, err := gitutil.EmptyTree(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// Known findings must still be reported to be recorded
+	known := diffcheck.Baseline
+	diffcheck.Baseline = nil
+	defer func() { diffcheck.Baseline = known }()
+
+	bl := baseline.New()
+	args := []string{"diff", "-U0", "--no-color", empty, "HEAD"}
+	err = gitutil.Stream(root, args, func(patch io.Reader) error {
+		_, err := diffcheck.Scan(context.Background(), patch, diffcheck.ScanOptions{
+			Report: func(r diffcheck.Report) error {
+				for _, w := range r.Warnings {
+					bl.Add(baseline.Entry{Fingerprint: w.Fingerprint, Rule: w.RuleID, Path: r.Path, Line: w.Line})
+				}
+				return nil
+			},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bl, nil
+}
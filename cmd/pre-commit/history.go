@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/ONSdigital/git-diff-check/gitutil"
+	"github.com/ONSdigital/git-diff-check/history"
+)
+
+// progressInterval is how often progress is reported while checking history
+const progressInterval = 5 * time.Second
+
+// interruptGrace is how long to wait for an interrupt to be seen when git fails,
+// as git is interrupted too
+const interruptGrace = 200 * time.Millisecond
+
+// runHistory checks every commit reachable from any ref, reporting each
+// finding once against the commit that first introduced it
+func runHistory(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	target := fs.String("p", ".", "(optional) path to repository")
+	checkpoint := fs.String("checkpoint", "", "(optional) file to save progress to, and resume from if it exists")
+	every := fs.Int("checkpoint-every", 1000, "number of commits to check between saving progress")
+	addCheckFlags(fs)
+	fs.Parse(args)
+
+	setupOutput()
+	cfg := configure(*target, rulePaths)
+
+	total, err := gitutil.Count(cfg.Root, "--all")
+	if err != nil {
+		log.Fatal("Failed to count commits:", err)
+	}
+
+	s := history.New()
+	if *checkpoint != "" {
+		resumed, err := history.Resume(*checkpoint)
+		switch {
+		case err == nil:
+			s = resumed
+			fmt.Fprintf(info, "Resuming from %s with %d commits already checked\n", *checkpoint, s.Checked())
+		case !os.IsNotExist(err):
+			log.Fatal("Failed to read checkpoint:", err)
+		}
+	}
+
+	save := func() {
+		if *checkpoint == "" {
+			return
+		}
+		if err := s.Save(*checkpoint); err != nil {
+			log.Fatal("Failed to save checkpoint:", err)
+		}
+	}
+
+	// Stop cleanly on interrupt so that progress can be saved
+	stop := make(chan struct{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		close(stop)
+	}()
+
+	fmt.Fprintf(info, "Running diff check on the history of %d commits\n", total)
+	last := time.Now()
+	progress := func() {
+		if *every > 0 && s.Checked()%*every == 0 {
+			save()
+		}
+		if time.Since(last) >= progressInterval {
+			fmt.Fprintf(info, "Checked %d of %d commits, %d with findings\n", s.Checked(), total, len(s.Results))
+			last = time.Now()
+		}
+	}
+
+	err = s.Run(cfg.Root, progress, stop)
+	if err != nil && err != history.ErrStopped {
+		// git gets the same interrupt, and may have exited before it was seen
+		select {
+		case <-stop:
+			err = history.ErrStopped
+		case <-time.After(interruptGrace):
+		}
+	}
+	if err == history.ErrStopped {
+		save()
+		if *checkpoint != "" {
+			fmt.Fprintf(os.Stderr, "Stopped after %d of %d commits, rerun with -checkpoint %s to resume\n", s.Checked(), total, *checkpoint)
+		} else {
+			fmt.Fprintf(os.Stderr, "Stopped after %d of %d commits\n", s.Checked(), total)
+		}
+		return rejected
+	}
+	if err != nil {
+		save()
+		log.Fatal("Failed to check history:", err)
+	}
+
+	// Finished, so there's nothing to resume
+	if *checkpoint != "" {
+		if err := os.Remove(*checkpoint); err != nil && !os.IsNotExist(err) {
+			log.Println("Failed to remove checkpoint:", err)
+		}
+	}
+
+	r := result{Target: *target, OK: true, Commits: []commitResult{}, Checked: s.Checked()}
+	for _, res := range s.Results {
+		c := commitResult{Commit: res.Commit, OK: true, Reports: res.Reports}
+		for _, report := range res.Reports {
+			if len(report.Warnings) > 0 {
+				c.OK = false
+			}
+		}
+		r.Commits = append(r.Commits, c)
+		r.OK = r.OK && c.OK
+	}
+
+	return finish(r)
+}
@@ -1,19 +1,25 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/ONSdigital/git-diff-check/baseline"
+	"github.com/ONSdigital/git-diff-check/config"
 	"github.com/ONSdigital/git-diff-check/diffcheck"
+	"github.com/ONSdigital/git-diff-check/gitutil"
 )
 
 const (
@@ -31,23 +37,97 @@ const (
 )
 
 var target = flag.String("p", "", "(optional) path to repository")
+var patchPath = flag.String("patch", "", "(optional) check the patch in the given file, or - for stdin, rather than the staged changes")
 var showVersion bool
 var showHelp bool
 
+// Options shared by the pre-commit check and the commands that check commits
+var (
+	verbose     bool
+	showMatches bool
+	format      string
+	rulePaths   listFlag
+)
+
+// info is where progress messages are written
+var info io.Writer = os.Stdout
+
 func init() {
 	flag.BoolVar(&showVersion, "version", false, "show current version")
 	flag.BoolVar(&showHelp, "help", false, "show usage")
+	addCheckFlags(flag.CommandLine)
+}
+
+// addCheckFlags adds the options shared by the pre-commit check and the
+// commands that check commits to fs
+func addCheckFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&verbose, "v", false, "verbose output, e.g. list files skipped by ignore patterns")
+	fs.StringVar(&format, "format", "text", "output format, one of: text, json, sarif")
+	fs.BoolVar(&showMatches, "show-matches", false, "show the full text of each match rather than redacting it")
+	fs.Var(&rulePaths, "rules", "(optional) JSON rule file or directory of rule files to load in addition to the built in rules. May be repeated")
+}
+
+// setupOutput checks the output options. Progress messages are sent to stderr
+// for machine readable formats so that stdout only contains the document.
+func setupOutput() {
+	if _, ok := writers[format]; !ok {
+		fmt.Fprintf(os.Stderr, "Unknown output format %q\n", format)
+		os.Exit(rejected)
+	}
+	if format != "text" {
+		info = os.Stderr
+	}
+	diffcheck.ShowMatches = showMatches
+}
+
+// listFlag is a flag that may be given multiple times
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
 }
 
 // Version is injected at build time
 var Version string
 
+// commands are the subcommands available in addition to the default
+// pre-commit check
+var commands = map[string]func(args []string) int{
+	"baseline":       runBaseline,
+	"prune-baseline": runPruneBaseline,
+	"pre-push":       runPrePush,
+	"scan":           runScan,
+	"history":        runHistory,
+}
+
 func main() {
 
+	// Installed as (or linked to) the pre-push hook
+	if strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe") == "pre-push" {
+		os.Exit(runPrePush(os.Args[1:]))
+	}
+
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+
 	flag.Parse()
 
 	if showHelp {
 		flag.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nCommands:")
+		fmt.Fprintln(os.Stderr, "  baseline\n    \trecord all findings in HEAD in "+baseline.DefaultFile)
+		fmt.Fprintln(os.Stderr, "  prune-baseline\n    \tremove baseline entries no longer found in HEAD")
+		fmt.Fprintln(os.Stderr, "  scan -range A..B | -since <ref>\n    \tcheck each commit in a range separately")
+		fmt.Fprintln(os.Stderr, "  history [-checkpoint file]\n    \tcheck every commit reachable from any ref, reporting each finding once")
+		fmt.Fprintln(os.Stderr, "  pre-push <remote> <url>\n    \tcheck each commit being pushed, reading the refs from stdin")
 		os.Exit(0)
 	}
 
@@ -60,6 +140,8 @@ func main() {
 		os.Exit(0)
 	}
 
+	setupOutput()
+
 	// Attempt to check for a new version and inform the user if this is so.
 	// If we can't connect or get the version for some reason then this is non-fatal
 	versionCheck()
@@ -67,63 +149,91 @@ func main() {
 	if *target == "" {
 		*target = "."
 	}
-	fmt.Printf("Running precommit diff check on '%s'\n", *target)
+	fmt.Fprintf(info, "Running precommit diff check on '%s'\n", *target)
 
-	// Import environmental feature flags
-	if useEntropyFeature := os.Getenv("DC_ENTROPY_EXPERIMENT"); useEntropyFeature == "1" {
-		fmt.Println("i) Experimental entropy checking enabled")
-		diffcheck.UseEntropy = true
+	configure(*target, rulePaths)
+	if diffcheck.UseEntropy {
+		fmt.Fprintln(info, "i) Experimental entropy checking enabled")
 	}
 
-	// Get where we are so we can get back
-	ex, err := os.Executable()
-	if err != nil {
-		log.Fatal("Couldn't get current dir:", err)
+	// A bare "-" is the same as -patch -
+	if *patchPath == "" && flag.Arg(0) == "-" {
+		*patchPath = "-"
+	}
+	if *patchPath != "" {
+		os.Exit(checkPatchFile(*target, *patchPath))
 	}
-	here := filepath.Dir(ex)
 
-	err = os.Chdir(*target)
+	// The diff is checked as git writes it out, so large staged files don't
+	// need to fit in memory
+	empty := false
+	r := result{Target: *target}
+	err := gitutil.Stream(*target, []string{"diff", "-U0", "--staged"}, func(patch io.Reader) error {
+		var err error
+		empty, r.OK, r.Reports, err = scanPatch(patch)
+		return err
+	})
 	if err != nil {
-		log.Fatal("Failed to change to target dir:", err)
+		log.Fatal("Failed to snoop:", err)
 	}
-	patch, err := exec.Command("git", "diff", "-U0", "--staged").CombinedOutput()
-	if err != nil {
-		log.Fatalf("Failed to run git command: %v (%s)", err, patch)
+
+	if empty {
+		fmt.Fprintln(info, "No changes to test - exiting")
 	}
-	os.Chdir(here)
+	os.Exit(finish(r))
+}
 
-	if len(patch) == 0 {
-		fmt.Println("No changes to test - exiting")
-		os.Exit(accepted)
+// scanPatch checks a patch as it's read, returning the reports for it. empty is
+// true if there was nothing to read.
+func scanPatch(patch io.Reader) (empty, ok bool, reports []diffcheck.Report, err error) {
+	br := bufio.NewReader(patch)
+	if _, err := br.Peek(1); err == io.EOF {
+		return true, true, nil, nil
 	}
 
-	ok, reports, err := diffcheck.SnoopPatch(patch)
-	if err != nil {
-		log.Fatal("Failed to snoop:", err)
+	ok, err = diffcheck.Scan(context.Background(), br, diffcheck.ScanOptions{
+		Report: func(r diffcheck.Report) error {
+			reports = append(reports, r)
+			return nil
+		},
+	})
+	return false, ok, reports, err
+}
+
+// finish writes the result in the chosen output format and returns the exit
+// code for it
+func finish(r result) int {
+	if err := writers[format](os.Stdout, r); err != nil {
+		log.Fatal("Failed to write output:", err)
 	}
+	if r.OK {
+		return accepted
+	}
+	return rejected
+}
 
-	if len(reports) > 0 {
-		fmt.Println("WARNING! Potential sensitive data found:")
+// configure imports the repository and global settings for the repository at
+// target, the rules given with -rules and the repository's baseline
+func configure(target string, rulePaths []string) *config.Config {
+	cfg, err := config.Load(target)
+	if err != nil {
+		log.Fatal("Failed to read config:", err)
+	}
 
-		for _, r := range reports {
-			fmt.Printf("Found in (%s)\n", r.Path)
-			for _, w := range r.Warnings {
-				if w.Type == "line" {
-					fmt.Printf("\t> [%s] %s (line %d)\n", w.Type, w.Description, w.Line)
-				} else {
-					fmt.Printf("\t> [%s] %s\n", w.Type, w.Description)
-				}
-			}
-			fmt.Println()
-		}
+	// Loaded along with the config's own rule files, so that `disable`
+	// applies to both
+	cfg.Rules = append(cfg.Rules, rulePaths...)
+	if err := cfg.Apply(); err != nil {
+		log.Fatal(err)
 	}
 
-	if ok {
-		fmt.Println("Diff probably ok!")
-		os.Exit(accepted)
+	bl, err := baseline.Read(filepath.Join(cfg.Root, baseline.DefaultFile))
+	if err != nil && !os.IsNotExist(err) {
+		log.Fatal("Failed to read baseline:", err)
 	}
-	fmt.Println("If you're VERY SURE these files are ok, rerun commit with --no-verify")
-	os.Exit(rejected)
+	diffcheck.Baseline = bl
+
+	return cfg
 }
 
 // VersionResponse is the response from the github verson call
@@ -139,30 +249,30 @@ func versionCheck() bool {
 
 	resp, err := netClient.Get(LatestVersion)
 	if err != nil {
-		fmt.Println("Failed to check for new versions" + err.Error())
+		fmt.Fprintln(info, "Failed to check for new versions"+err.Error())
 		return false
 	}
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Println("Failed to check for new versions" + err.Error())
+		fmt.Fprintln(info, "Failed to check for new versions"+err.Error())
 		return false
 	}
 
 	var v VersionResponse
 	err = json.Unmarshal(body, &v)
 	if err != nil {
-		fmt.Println("Failed to check for new versions" + err.Error())
+		fmt.Fprintln(info, "Failed to check for new versions"+err.Error())
 		return false
 	}
 
 	if len(v.TagName) == 0 {
-		fmt.Println("Failed to parse version from github response")
+		fmt.Fprintln(info, "Failed to parse version from github response")
 		return false
 	}
 
 	if v.TagName != Version {
-		fmt.Printf("\n** Precommit: New version %s available (installed %s) **\n\n", v.TagName, Version)
+		fmt.Fprintf(info, "\n** Precommit: New version %s available (installed %s) **\n\n", v.TagName, Version)
 		return true
 	}
 
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ONSdigital/git-diff-check/diffcheck"
+	"github.com/ONSdigital/git-diff-check/rule"
+)
+
+func TestConfigureDisablesRulesFromFlag(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configure")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// configure replaces the package settings, so put them back afterwards
+	sets, detectors, ignore, thresholds := rule.Sets, diffcheck.Detectors, diffcheck.Ignore, diffcheck.Thresholds
+	defer func() {
+		rule.Sets, diffcheck.Detectors, diffcheck.Ignore, diffcheck.Thresholds = sets, detectors, ignore, thresholds
+		diffcheck.Baseline = nil
+	}()
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	defer os.Setenv("XDG_CONFIG_HOME", xdg)
+	os.Setenv("XDG_CONFIG_HOME", dir)
+
+	files := map[string]string{
+		".diffcheck.yml": "disable: [internal-api-token, private-ssh-key-rsa]\n",
+		"rules.json": `[
+			{"id": "internal-api-token", "type": "regex", "pattern": "INT-[0-9A-F]{32}", "caption": "Internal API token"},
+			{"id": "internal-service-account", "part": "filename", "type": "match", "pattern": "service-account.json", "caption": "Internal service account file"}
+		]`,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	configure(dir, []string{filepath.Join(dir, "rules.json")})
+
+	loaded := map[string]bool{}
+	for _, rules := range rule.Sets {
+		for _, r := range rules {
+			loaded[r.ID] = true
+		}
+	}
+	for id, expected := range map[string]bool{
+		"internal-api-token":       false,
+		"internal-service-account": true,
+		"private-ssh-key-rsa":      false,
+		"aws-access-key":           true,
+	} {
+		if loaded[id] != expected {
+			t.Errorf("Expected rule %s loaded to be %v, got %v", id, expected, loaded[id])
+		}
+	}
+}
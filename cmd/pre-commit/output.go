@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/git-diff-check/diffcheck"
+	"github.com/ONSdigital/git-diff-check/gitutil"
+	"github.com/ONSdigital/git-diff-check/ignore"
+)
+
+// result is the outcome of a check, ready to be written out in one of the
+// output formats
+type result struct {
+	// What was checked, e.g. the repository path
+	Target string
+
+	OK bool
+
+	// Reports from checking a single patch, e.g. the staged changes
+	Reports []diffcheck.Report
+
+	// Results for each commit when checking a range of commits
+	Commits []commitResult
+
+	// Number of commits checked, if only those with findings are in Commits
+	Checked int
+}
+
+// commitResult is the outcome of checking a single commit
+type commitResult struct {
+	gitutil.Commit
+
+	OK      bool
+	Reports []diffcheck.Report
+}
+
+// eachReport calls fn with every report in the result, along with the commit
+// it came from (nil if not checking commits)
+func (r result) eachReport(fn func(c *commitResult, report diffcheck.Report)) {
+	for _, report := range r.Reports {
+		fn(nil, report)
+	}
+	for i := range r.Commits {
+		for _, report := range r.Commits[i].Reports {
+			fn(&r.Commits[i], report)
+		}
+	}
+}
+
+// writers are the available output formats for -format
+var writers = map[string]func(w io.Writer, r result) error{
+	"text":  writeText,
+	"json":  writeJSON,
+	"sarif": writeSARIF,
+}
+
+// writeText writes the result in the human readable format
+func writeText(w io.Writer, r result) error {
+	if r.Commits == nil {
+		writeTextReports(w, r.OK, r.Reports)
+
+		if r.OK {
+			fmt.Fprintln(w, "Diff probably ok!")
+		} else {
+			fmt.Fprintln(w, "If you're VERY SURE these files are ok, rerun commit with --no-verify")
+		}
+		return nil
+	}
+
+	total := len(r.Commits)
+	if r.Checked > total {
+		total = r.Checked
+	}
+
+	failed := 0
+	for _, c := range r.Commits {
+		if c.OK && len(c.Reports) == 0 {
+			continue
+		}
+		if !c.OK {
+			failed++
+		}
+		fmt.Fprintf(w, "Commit %s by %s on %s: %s\n", c.Short(), c.Author, c.Date.Format("2006-01-02"), c.Subject)
+		writeTextReports(w, c.OK, c.Reports)
+	}
+
+	if r.OK {
+		fmt.Fprintf(w, "All %d commits probably ok!\n", total)
+	} else {
+		fmt.Fprintf(w, "%d of %d commits contain potentially sensitive data\n", failed, total)
+	}
+	return nil
+}
+
+// writeTextReports writes out the warnings and suppressed warnings for a
+// single patch
+func writeTextReports(w io.Writer, ok bool, reports []diffcheck.Report) {
+	if verbose {
+		for _, report := range reports {
+			if report.Ignored != ignore.None {
+				fmt.Fprintf(w, "i) Skipped %s rules for (%s)\n", report.Ignored, report.Path)
+			}
+		}
+	}
+
+	if !ok {
+		fmt.Fprintln(w, "WARNING! Potential sensitive data found:")
+
+		for _, report := range reports {
+			if len(report.Warnings) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "Found in (%s)\n", report.Path)
+			writeTextWarnings(w, report.Warnings)
+			fmt.Fprintln(w)
+		}
+	}
+
+	if suppressed := countSuppressed(reports); suppressed > 0 {
+		fmt.Fprintf(w, "Suppressed (%d) - allowed by inline diffcheck:allow markers:\n", suppressed)
+
+		for _, report := range reports {
+			if len(report.Suppressed) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "Found in (%s)\n", report.Path)
+			writeTextWarnings(w, report.Suppressed)
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+func writeTextWarnings(w io.Writer, warnings []diffcheck.Warning) {
+	for _, warning := range warnings {
+		where := ""
+		if warning.Cell != nil {
+			where = ", " + warning.Cell.String()
+		}
+		if len(warning.Decoded) > 0 {
+			where += ", decoded from " + strings.Join(warning.Decoded, " > ")
+		}
+		if warning.Type == "line" && warning.EndLine > warning.Line {
+			fmt.Fprintf(w, "\t> [%s] %s (lines %d-%d%s, rule %s)\n", warning.Type, warning.Description, warning.Line, warning.EndLine, where, warning.RuleID)
+		} else if warning.Type == "line" {
+			fmt.Fprintf(w, "\t> [%s] %s (line %d%s, rule %s)\n", warning.Type, warning.Description, warning.Line, where, warning.RuleID)
+		} else {
+			fmt.Fprintf(w, "\t> [%s] %s (rule %s)\n", warning.Type, warning.Description, warning.RuleID)
+		}
+	}
+}
+
+func countSuppressed(reports []diffcheck.Report) int {
+	n := 0
+	for _, r := range reports {
+		n += len(r.Suppressed)
+	}
+	return n
+}
+
+// jsonVersion is the version of the JSON output document. It is increased
+// whenever a field is removed or changes meaning - new fields may be added
+// without changing it.
+const jsonVersion = 1
+
+type (
+	jsonDocument struct {
+		Version int          `json:"version"`
+		Tool    jsonTool     `json:"tool"`
+		Target  string       `json:"target"`
+		Verdict string       `json:"verdict"` // "accepted" or "rejected"
+		OK      bool         `json:"ok"`
+		Summary jsonSummary  `json:"summary"`
+		Reports []jsonReport `json:"reports"`
+		Commits []jsonCommit `json:"commits,omitempty"`
+	}
+
+	jsonCommit struct {
+		SHA     string    `json:"sha"`
+		Author  string    `json:"author"`
+		Date    time.Time `json:"date"`
+		Subject string    `json:"subject"`
+		OK      bool      `json:"ok"`
+	}
+
+	jsonTool struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	jsonSummary struct {
+		Files      int `json:"files"`
+		Warnings   int `json:"warnings"`
+		Suppressed int `json:"suppressed"`
+
+		// Number of commits checked, when checking commits
+		Commits int `json:"commits,omitempty"`
+	}
+
+	jsonReport struct {
+		Commit     string        `json:"commit,omitempty"`
+		Path       string        `json:"path"`
+		OldPath    string        `json:"old_path"`
+		Ignored    string        `json:"ignored,omitempty"`
+		Warnings   []jsonWarning `json:"warnings"`
+		Suppressed []jsonWarning `json:"suppressed"`
+	}
+
+	jsonWarning struct {
+		Type        string    `json:"type"`
+		RuleID      string    `json:"rule_id"`
+		Severity    string    `json:"severity"`
+		Category    string    `json:"category,omitempty"`
+		Description string    `json:"description"`
+		Line        int       `json:"line"`
+		EndLine     int       `json:"end_line,omitempty"`
+		StartColumn int       `json:"start_column,omitempty"`
+		EndColumn   int       `json:"end_column,omitempty"`
+		Match       string    `json:"match,omitempty"`
+		Fingerprint string    `json:"fingerprint,omitempty"`
+		Cell        *jsonCell `json:"cell,omitempty"`
+		Decoded     []string  `json:"decoded,omitempty"`
+	}
+
+	jsonCell struct {
+		Number int    `json:"number"`
+		Type   string `json:"type,omitempty"`
+		Part   string `json:"part"`
+	}
+)
+
+// writeJSON writes the result as a jsonDocument. See the README for a
+// description of the fields.
+func writeJSON(w io.Writer, r result) error {
+	doc := jsonDocument{
+		Version: jsonVersion,
+		Tool:    jsonTool{Name: "git-diff-check", Version: Version},
+		Target:  r.Target,
+		Verdict: verdict(r.OK),
+		OK:      r.OK,
+		Reports: []jsonReport{},
+	}
+
+	doc.Summary.Commits = len(r.Commits)
+	if r.Checked > doc.Summary.Commits {
+		doc.Summary.Commits = r.Checked
+	}
+	for _, c := range r.Commits {
+		doc.Commits = append(doc.Commits, jsonCommit{SHA: c.SHA, Author: c.Author, Date: c.Date, Subject: c.Subject, OK: c.OK})
+	}
+
+	r.eachReport(func(c *commitResult, report diffcheck.Report) {
+		jr := jsonReport{
+			Path:       report.Path,
+			OldPath:    report.OldPath,
+			Warnings:   toJSONWarnings(report.Warnings),
+			Suppressed: toJSONWarnings(report.Suppressed),
+		}
+		if c != nil {
+			jr.Commit = c.SHA
+		}
+		if report.Ignored != ignore.None {
+			jr.Ignored = report.Ignored.String()
+		}
+		doc.Reports = append(doc.Reports, jr)
+
+		if len(report.Warnings) > 0 {
+			doc.Summary.Files++
+		}
+		doc.Summary.Warnings += len(report.Warnings)
+		doc.Summary.Suppressed += len(report.Suppressed)
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+func toJSONWarnings(warnings []diffcheck.Warning) []jsonWarning {
+	out := []jsonWarning{}
+	for _, w := range warnings {
+		var cell *jsonCell
+		if w.Cell != nil {
+			cell = &jsonCell{Number: w.Cell.Number, Type: w.Cell.Type, Part: w.Cell.Part}
+		}
+		out = append(out, jsonWarning{
+			Type:        w.Type,
+			RuleID:      w.RuleID,
+			Severity:    w.Severity,
+			Category:    w.Category,
+			Description: w.Description,
+			Line:        w.Line,
+			EndLine:     w.EndLine,
+			StartColumn: w.StartColumn,
+			EndColumn:   w.EndColumn,
+			Match:       w.Match,
+			Fingerprint: w.Fingerprint,
+			Cell:        cell,
+			Decoded:     w.Decoded,
+		})
+	}
+	return out
+}
+
+func verdict(ok bool) string {
+	if ok {
+		return "accepted"
+	}
+	return "rejected"
+}
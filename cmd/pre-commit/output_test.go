@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/git-diff-check/diffcheck"
+	"github.com/ONSdigital/git-diff-check/gitutil"
+	"github.com/ONSdigital/git-diff-check/ignore"
+)
+
+func TestWriteJSON(t *testing.T) {
+	type summary struct {
+		Files      int
+		Warnings   int
+		Suppressed int
+		Commits    int
+	}
+
+	date := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var tests = []struct {
+		Name    string
+		Result  result
+		Verdict string
+		Summary summary
+		Reports int
+		Commits int
+	}{
+		{
+			Name:    "clean",
+			Result:  result{Target: "repo", OK: true, Reports: []diffcheck.Report{}},
+			Verdict: "accepted",
+		},
+		{
+			Name: "warnings",
+			Result: result{Target: "repo", Reports: []diffcheck.Report{
+				{Path: "config.py", OldPath: "config.py", Warnings: []diffcheck.Warning{awsWarning, entropyWarning}},
+				{Path: "id_rsa", Warnings: []diffcheck.Warning{keyFileWarning}},
+				{Path: "b.py", Suppressed: []diffcheck.Warning{entropyWarning}},
+				{Path: "vendor/x.js", Ignored: ignore.Line},
+			}},
+			Verdict: "rejected",
+			Summary: summary{Files: 2, Warnings: 3, Suppressed: 1},
+			Reports: 4,
+		},
+		{
+			Name: "commits",
+			Result: result{Target: "repo", Checked: 5, Commits: []commitResult{
+				{Commit: gitutil.Commit{SHA: "abc123", Author: "A Person", Date: date, Subject: "Add config"}, Reports: []diffcheck.Report{
+					{Path: "config.py", Warnings: []diffcheck.Warning{awsWarning}},
+				}},
+				{Commit: gitutil.Commit{SHA: "def456", Author: "A Person", Date: date, Subject: "Allow key"}, OK: true, Reports: []diffcheck.Report{
+					{Path: "id_rsa", Suppressed: []diffcheck.Warning{keyFileWarning}},
+				}},
+			}},
+			Verdict: "rejected",
+			Summary: summary{Files: 1, Warnings: 1, Suppressed: 1, Commits: 5},
+			Reports: 2,
+			Commits: 2,
+		},
+	}
+
+	for _, tc := range tests {
+		var buf bytes.Buffer
+		if err := writeJSON(&buf, tc.Result); err != nil {
+			t.Fatalf("%s: Expected no error, but got: %v", tc.Name, err)
+		}
+
+		// Decoded into maps, so that the field names are checked as well as
+		// the values
+		var doc map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatalf("%s: Expected valid JSON, but got: %v\n%s", tc.Name, err, buf.String())
+		}
+
+		expectedFields := "ok reports summary target tool verdict version"
+		if tc.Commits > 0 {
+			expectedFields = "commits " + expectedFields
+		}
+		shouldEqual(tc.Name+" fields", fields(doc), expectedFields, t)
+		shouldEqual(tc.Name+" verdict", doc["verdict"].(string), tc.Verdict, t)
+		if ok := doc["ok"].(bool); ok != (tc.Verdict == "accepted") {
+			t.Errorf("%s: Expected ok to be %v with verdict %s", tc.Name, !ok, tc.Verdict)
+		}
+		if doc["version"].(float64) != jsonVersion {
+			t.Errorf("%s: Expected version %d, got %v", tc.Name, jsonVersion, doc["version"])
+		}
+		shouldEqual(tc.Name+" target", doc["target"].(string), "repo", t)
+		shouldEqual(tc.Name+" tool", doc["tool"].(map[string]interface{})["name"].(string), "git-diff-check", t)
+
+		s := doc["summary"].(map[string]interface{})
+		got := summary{
+			Files:      int(s["files"].(float64)),
+			Warnings:   int(s["warnings"].(float64)),
+			Suppressed: int(s["suppressed"].(float64)),
+		}
+		if commits, ok := s["commits"]; ok {
+			got.Commits = int(commits.(float64))
+		}
+		if got != tc.Summary {
+			t.Errorf("%s: Expected summary %+v, got %+v", tc.Name, tc.Summary, got)
+		}
+
+		reports := doc["reports"].([]interface{})
+		if len(reports) != tc.Reports {
+			t.Errorf("%s: Expected %d reports, got %d", tc.Name, tc.Reports, len(reports))
+		}
+		if tc.Commits > 0 {
+			commits := doc["commits"].([]interface{})
+			if len(commits) != tc.Commits {
+				t.Errorf("%s: Expected %d commits, got %d", tc.Name, tc.Commits, len(commits))
+			}
+			shouldEqual(tc.Name+" commit fields", fields(commits[0].(map[string]interface{})), "author date ok sha subject", t)
+		}
+	}
+}
+
+func TestWriteJSONReports(t *testing.T) {
+	r := result{Commits: []commitResult{
+		{Commit: gitutil.Commit{SHA: "abc123"}, Reports: []diffcheck.Report{
+			{Path: "notebook.ipynb", OldPath: "notebook.ipynb", Ignored: ignore.Line, Warnings: []diffcheck.Warning{
+				{
+					Type: "line", RuleID: "aws-access-key", Severity: "high", Category: "secret", Description: "Possible AWS Access Key",
+					Line: 44, EndLine: 45, StartColumn: 14, EndColumn: 34, Match: "AKIA****", Fingerprint: "f1",
+					Cell: &diffcheck.NotebookCell{Number: 3, Type: "code", Part: "source"}, Decoded: []string{"base64"},
+				},
+			}},
+			{Path: "id_rsa", Suppressed: []diffcheck.Warning{keyFileWarning}},
+		}},
+	}}
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, r); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	var doc struct {
+		Reports []map[string]interface{} `json:"reports"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected valid JSON, but got: %v\n%s", err, buf.String())
+	}
+	if len(doc.Reports) != 2 {
+		t.Fatalf("Expected 2 reports, got %d", len(doc.Reports))
+	}
+
+	report := doc.Reports[0]
+	shouldEqual("report fields", fields(report), "commit ignored old_path path suppressed warnings", t)
+	shouldEqual("commit", report["commit"].(string), "abc123", t)
+	shouldEqual("ignored", report["ignored"].(string), ignore.Line.String(), t)
+
+	warning := report["warnings"].([]interface{})[0].(map[string]interface{})
+	shouldEqual("warning fields", fields(warning),
+		"category cell decoded description end_column end_line fingerprint line match rule_id severity start_column type", t)
+	shouldEqual("rule ID", warning["rule_id"].(string), "aws-access-key", t)
+	shouldEqual("cell fields", fields(warning["cell"].(map[string]interface{})), "number part type", t)
+
+	// File warnings, and empty lists, are still written out in full
+	report = doc.Reports[1]
+	if warnings := report["warnings"].([]interface{}); len(warnings) != 0 {
+		t.Errorf("Expected an empty list of warnings, got %v", warnings)
+	}
+	suppressed := report["suppressed"].([]interface{})[0].(map[string]interface{})
+	shouldEqual("file warning fields", fields(suppressed), "description end_line fingerprint line rule_id severity type", t)
+	if suppressed["line"].(float64) != -1 {
+		t.Errorf("Expected a file warning to have line -1, got %v", suppressed["line"])
+	}
+}
+
+// fields returns the sorted keys of a decoded JSON object
+func fields(m map[string]interface{}) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, " ")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/ONSdigital/git-diff-check/diffcheck"
+	"github.com/ONSdigital/git-diff-check/gitutil"
+)
+
+// checkPatchFile checks a patch read from path, or stdin if path is "-",
+// rather than asking git for the staged changes. An mbox of patches, e.g.
+// from git format-patch, is checked one patch at a time as if each were a
+// commit.
+func checkPatchFile(target, path string) int {
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatal("Failed to read patch:", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	// Plain diffs are checked as they're read, so only an mbox needs to be
+	// held in memory to be split up
+	br := bufio.NewReader(in)
+	first, err := br.ReadSlice('\n')
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		log.Fatal("Failed to read patch:", err)
+	}
+	first = append([]byte(nil), first...)
+	if !gitutil.IsMbox(first) {
+		_, ok, reports, err := scanPatch(io.MultiReader(bytes.NewReader(first), br))
+		if err != nil {
+			log.Fatal("Failed to snoop:", err)
+		}
+		return finish(result{Target: path, OK: ok, Reports: reports})
+	}
+
+	rest, err := ioutil.ReadAll(br)
+	if err != nil {
+		log.Fatal("Failed to read patch:", err)
+	}
+	patch := append(first, rest...)
+
+	mails, err := gitutil.SplitMbox(patch)
+	if err != nil {
+		log.Fatal("Failed to read patches:", err)
+	}
+
+	r := result{Target: path, OK: true, Commits: []commitResult{}}
+	for _, m := range mails {
+		ok, reports, err := diffcheck.SnoopPatch(m.Patch)
+		if err != nil {
+			log.Fatal("Failed to snoop:", err)
+		}
+		r.Commits = append(r.Commits, commitResult{Commit: m.Commit, OK: ok, Reports: reports})
+		r.OK = r.OK && ok
+	}
+	return finish(r)
+}
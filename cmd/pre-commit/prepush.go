@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ONSdigital/git-diff-check/gitutil"
+)
+
+// runPrePush checks each commit that a push would send to the remote, reading
+// the refs being pushed from stdin as git does for a pre-push hook. Catches
+// commits made with --no-verify, or by tools that skip the pre-commit hook.
+func runPrePush(args []string) int {
+	fs := flag.NewFlagSet("pre-push", flag.ExitOnError)
+	target := fs.String("p", ".", "(optional) path to repository")
+	addCheckFlags(fs)
+	fs.Parse(args)
+
+	// git passes the name and URL of the remote. When pushing to a URL rather
+	// than a named remote, both are the URL, and Outgoing falls back to the
+	// tracking branches of every remote.
+	remote := fs.Arg(0)
+
+	refs, err := gitutil.ParsePushRefs(os.Stdin)
+	if err != nil {
+		log.Fatal("Failed to read refs being pushed:", err)
+	}
+
+	setupOutput()
+	cfg := configure(*target, rulePaths)
+
+	shas := []string{}
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		outgoing, err := gitutil.Outgoing(cfg.Root, remote, ref)
+		if err != nil {
+			log.Fatalf("Failed to list commits for %s: %v", ref.LocalRef, err)
+		}
+		for _, sha := range outgoing {
+			if !seen[sha] {
+				seen[sha] = true
+				shas = append(shas, sha)
+			}
+		}
+	}
+	if len(shas) == 0 {
+		return accepted
+	}
+
+	fmt.Fprintf(info, "Running diff check on %d commits being pushed\n", len(shas))
+	r := checkCommits(*target, cfg.Root, shas)
+	code := finish(r)
+	if !r.OK && format == "text" {
+		fmt.Fprintln(os.Stdout, "If you're VERY SURE these commits are ok, rerun push with --no-verify")
+	}
+	return code
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/ONSdigital/git-diff-check/diffcheck"
+	"github.com/ONSdigital/git-diff-check/rule"
+)
+
+// SARIF 2.1.0 output for code scanning tools. Only the parts of the format
+// that are needed are modelled here - see
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// Key for the finding fingerprint in each result's partialFingerprints
+	sarifFingerprintKey = "diffcheck/v1"
+)
+
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+
+	sarifDriver struct {
+		Name           string            `json:"name"`
+		Version        string            `json:"version,omitempty"`
+		InformationURI string            `json:"informationUri"`
+		Rules          []sarifDescriptor `json:"rules"`
+	}
+
+	sarifDescriptor struct {
+		ID                   string             `json:"id"`
+		ShortDescription     sarifMessage       `json:"shortDescription"`
+		FullDescription      *sarifMessage      `json:"fullDescription,omitempty"`
+		Help                 *sarifMessage      `json:"help,omitempty"`
+		DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+		Properties           sarifProperties    `json:"properties"`
+	}
+
+	sarifConfiguration struct {
+		Level string `json:"level"`
+	}
+
+	sarifProperties struct {
+		Tags []string `json:"tags,omitempty"`
+
+		// Used by GitHub code scanning to rank security results
+		SecuritySeverity string `json:"security-severity,omitempty"`
+	}
+
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+
+	sarifResult struct {
+		RuleID              string             `json:"ruleId"`
+		RuleIndex           int                `json:"ruleIndex"`
+		Level               string             `json:"level"`
+		Message             sarifMessage       `json:"message"`
+		Locations           []sarifLocation    `json:"locations"`
+		PartialFingerprints map[string]string  `json:"partialFingerprints,omitempty"`
+		Suppressions        []sarifSuppression `json:"suppressions,omitempty"`
+		Properties          *sarifResultProps  `json:"properties,omitempty"`
+	}
+
+	sarifResultProps struct {
+		// The commit the finding was introduced in, when checking commits
+		Commit string `json:"commit,omitempty"`
+
+		// The notebook cell the finding is in, e.g. "cell 3 code output"
+		Cell string `json:"cell,omitempty"`
+
+		// The encodings the match was found inside, e.g. ["base64"]
+		Decoded []string `json:"decoded,omitempty"`
+	}
+
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+
+		// Not set for file name findings, which apply to the whole file
+		Region *sarifRegion `json:"region,omitempty"`
+	}
+
+	sarifArtifactLocation struct {
+		URI       string `json:"uri"`
+		URIBaseID string `json:"uriBaseId"`
+	}
+
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		EndLine     int `json:"endLine,omitempty"`
+		StartColumn int `json:"startColumn,omitempty"`
+		EndColumn   int `json:"endColumn,omitempty"`
+	}
+
+	sarifSuppression struct {
+		Kind          string `json:"kind"`
+		Justification string `json:"justification"`
+	}
+)
+
+// sarifLevels maps rule severities to SARIF result levels
+var sarifLevels = map[string]string{
+	rule.Low:      "note",
+	rule.Medium:   "warning",
+	rule.High:     "error",
+	rule.Critical: "error",
+}
+
+// sarifSecuritySeverities maps rule severities to a CVSS style score
+var sarifSecuritySeverities = map[string]string{
+	rule.Low:      "2.0",
+	rule.Medium:   "5.5",
+	rule.High:     "8.0",
+	rule.Critical: "9.5",
+}
+
+// enabledSets returns the names of the rulesets that are run - "file" and
+// "line", and those of any enabled ruleset detectors, e.g. "pii"
+func enabledSets() []string {
+	sets := []string{"file", "line"}
+	detectors := diffcheck.Detectors
+	if detectors == nil {
+		detectors = diffcheck.DefaultDetectors()
+	}
+	for _, d := range detectors {
+		name := d.Name()
+		if _, ok := rule.Sets[name]; ok && name != "file" && name != "line" {
+			sets = append(sets, name)
+		}
+	}
+	return sets
+}
+
+// writeSARIF writes the result as a SARIF log with a single run. Every loaded
+// rule in an enabled ruleset is described, and each warning becomes a result.
+// Suppressed warnings are included as results with an in source suppression.
+func writeSARIF(w io.Writer, r result) error {
+	driver := sarifDriver{
+		Name:           "git-diff-check",
+		Version:        Version,
+		InformationURI: "https://github.com/" + Repository,
+		Rules:          []sarifDescriptor{},
+	}
+
+	index := map[string]int{}
+	for _, set := range enabledSets() {
+		for _, rl := range rule.Sets[set] {
+			index[rl.ID] = len(driver.Rules)
+			driver.Rules = append(driver.Rules, sarifRule(rl))
+		}
+	}
+
+	// Warnings that don't come from a loaded rule, e.g. the entropy check,
+	// are described from the warning itself
+	ruleIndex := func(w diffcheck.Warning) int {
+		i, ok := index[w.RuleID]
+		if !ok {
+			i = len(driver.Rules)
+			index[w.RuleID] = i
+			driver.Rules = append(driver.Rules, sarifRule(rule.Rule{ID: w.RuleID, Caption: w.Description, Severity: w.Severity}))
+		}
+		return i
+	}
+
+	results := []sarifResult{}
+	r.eachReport(func(c *commitResult, report diffcheck.Report) {
+		add := func(res sarifResult) {
+			if c != nil {
+				if res.Properties == nil {
+					res.Properties = &sarifResultProps{}
+				}
+				res.Properties.Commit = c.SHA
+			}
+			results = append(results, res)
+		}
+		for _, warning := range report.Warnings {
+			add(sarifWarning(report, warning, ruleIndex(warning)))
+		}
+		for _, warning := range report.Suppressed {
+			res := sarifWarning(report, warning, ruleIndex(warning))
+			res.Suppressions = []sarifSuppression{{Kind: "inSource", Justification: "diffcheck:allow"}}
+			add(res)
+		}
+	})
+
+	doc := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{
+			{Tool: sarifTool{Driver: driver}, Results: results},
+		},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// sarifRule describes a rule as a SARIF reportingDescriptor
+func sarifRule(r rule.Rule) sarifDescriptor {
+	d := sarifDescriptor{
+		ID:                   r.ID,
+		ShortDescription:     sarifMessage{Text: r.Caption},
+		DefaultConfiguration: sarifConfiguration{Level: sarifLevel(r.Severity)},
+		Properties: sarifProperties{
+			Tags:             append([]string{"security"}, r.Tags...),
+			SecuritySeverity: sarifSecuritySeverities[r.Severity],
+		},
+	}
+	if r.Description != "" {
+		d.FullDescription = &sarifMessage{Text: r.Description}
+	}
+	if r.Remediation != "" {
+		d.Help = &sarifMessage{Text: r.Remediation}
+	}
+	return d
+}
+
+// sarifWarning converts a warning into a SARIF result
+func sarifWarning(report diffcheck.Report, w diffcheck.Warning, ruleIndex int) sarifResult {
+	location := sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: report.Path, URIBaseID: "%SRCROOT%"},
+	}
+	if w.Line > 0 {
+		location.Region = &sarifRegion{StartLine: w.Line, EndLine: w.EndLine, StartColumn: w.StartColumn, EndColumn: w.EndColumn}
+	}
+
+	res := sarifResult{
+		RuleID:    w.RuleID,
+		RuleIndex: ruleIndex,
+		Level:     sarifLevel(w.Severity),
+		Message:   sarifMessage{Text: w.Description},
+		Locations: []sarifLocation{{PhysicalLocation: location}},
+	}
+	if w.Fingerprint != "" {
+		res.PartialFingerprints = map[string]string{sarifFingerprintKey: w.Fingerprint}
+	}
+	if w.Cell != nil || len(w.Decoded) > 0 {
+		res.Properties = &sarifResultProps{Decoded: w.Decoded}
+		if w.Cell != nil {
+			res.Properties.Cell = w.Cell.String()
+		}
+	}
+	return res
+}
+
+func sarifLevel(severity string) string {
+	if level, ok := sarifLevels[severity]; ok {
+		return level
+	}
+	return "warning"
+}
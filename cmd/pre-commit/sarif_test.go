@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/ONSdigital/git-diff-check/diffcheck"
+	"github.com/ONSdigital/git-diff-check/gitutil"
+	"github.com/ONSdigital/git-diff-check/rule"
+)
+
+// sarifOutput is the part of a SARIF log checked by the tests, decoded with the
+// field names from the specification rather than the writer's own types
+type sarifOutput struct {
+	Version string `json:"version"`
+	Runs    []struct {
+		Tool struct {
+			Driver struct {
+				Name  string `json:"name"`
+				Rules []struct {
+					ID string `json:"id"`
+				} `json:"rules"`
+			} `json:"driver"`
+		} `json:"tool"`
+		Results []sarifOutputResult `json:"results"`
+	} `json:"runs"`
+}
+
+type sarifOutputResult struct {
+	RuleID    string `json:"ruleId"`
+	RuleIndex int    `json:"ruleIndex"`
+	Level     string `json:"level"`
+	Locations []struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region *struct {
+				StartLine   int `json:"startLine"`
+				EndLine     int `json:"endLine"`
+				StartColumn int `json:"startColumn"`
+				EndColumn   int `json:"endColumn"`
+			} `json:"region"`
+		} `json:"physicalLocation"`
+	} `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Suppressions        []struct {
+		Kind string `json:"kind"`
+	} `json:"suppressions"`
+	Properties *struct {
+		Commit  string   `json:"commit"`
+		Cell    string   `json:"cell"`
+		Decoded []string `json:"decoded"`
+	} `json:"properties"`
+}
+
+var (
+	awsWarning = diffcheck.Warning{
+		Type: "line", RuleID: "aws-access-key", Severity: rule.High, Description: "Possible AWS Access Key",
+		Line: 3, EndLine: 3, StartColumn: 8, EndColumn: 28, Fingerprint: "f1",
+	}
+	entropyWarning = diffcheck.Warning{
+		Type: "line", RuleID: "high-entropy-string", Severity: rule.Medium, Description: "Possible secret",
+		Line: 7, EndLine: 7, StartColumn: 5, EndColumn: 37, Fingerprint: "f2",
+	}
+	keyFileWarning = diffcheck.Warning{
+		Type: "file", RuleID: "private-ssh-key-rsa", Severity: rule.High, Description: "Private SSH key",
+		Line: -1, EndLine: -1, Fingerprint: "f3",
+	}
+)
+
+func TestWriteSARIF(t *testing.T) {
+	type expected struct {
+		RuleID     string
+		Level      string
+		URI        string
+		StartLine  int // 0 if there should be no region
+		Suppressed bool
+		Commit     string
+	}
+
+	var tests = []struct {
+		Name     string
+		Result   result
+		Expected []expected
+	}{
+		{
+			Name: "line and file warnings",
+			Result: result{Reports: []diffcheck.Report{
+				{Path: "config.py", Warnings: []diffcheck.Warning{awsWarning}},
+				{Path: "id_rsa", Warnings: []diffcheck.Warning{keyFileWarning}},
+			}},
+			Expected: []expected{
+				{RuleID: "aws-access-key", Level: "error", URI: "config.py", StartLine: 3},
+				{RuleID: "private-ssh-key-rsa", Level: "error", URI: "id_rsa"},
+			},
+		},
+		{
+			Name: "warnings without a loaded rule",
+			Result: result{Reports: []diffcheck.Report{
+				{Path: "a.py", Warnings: []diffcheck.Warning{entropyWarning, awsWarning}},
+				{Path: "b.py", Warnings: []diffcheck.Warning{entropyWarning}, Suppressed: []diffcheck.Warning{entropyWarning}},
+			}},
+			Expected: []expected{
+				{RuleID: "high-entropy-string", Level: "warning", URI: "a.py", StartLine: 7},
+				{RuleID: "aws-access-key", Level: "error", URI: "a.py", StartLine: 3},
+				{RuleID: "high-entropy-string", Level: "warning", URI: "b.py", StartLine: 7},
+				{RuleID: "high-entropy-string", Level: "warning", URI: "b.py", StartLine: 7, Suppressed: true},
+			},
+		},
+		{
+			Name: "commits",
+			Result: result{Commits: []commitResult{
+				{Commit: gitutil.Commit{SHA: "abc123"}, Reports: []diffcheck.Report{{Path: "config.py", Warnings: []diffcheck.Warning{awsWarning}}}},
+				{Commit: gitutil.Commit{SHA: "def456"}, Reports: []diffcheck.Report{{Path: "id_rsa", Suppressed: []diffcheck.Warning{keyFileWarning}}}},
+			}},
+			Expected: []expected{
+				{RuleID: "aws-access-key", Level: "error", URI: "config.py", StartLine: 3, Commit: "abc123"},
+				{RuleID: "private-ssh-key-rsa", Level: "error", URI: "id_rsa", Suppressed: true, Commit: "def456"},
+			},
+		},
+		{
+			Name:   "no warnings",
+			Result: result{OK: true, Reports: []diffcheck.Report{}},
+		},
+	}
+
+	loaded := map[string]bool{}
+	for _, set := range []string{"file", "line"} {
+		for _, r := range rule.Sets[set] {
+			loaded[r.ID] = true
+		}
+	}
+
+	for _, tc := range tests {
+		var buf bytes.Buffer
+		if err := writeSARIF(&buf, tc.Result); err != nil {
+			t.Fatalf("%s: Expected no error, but got: %v", tc.Name, err)
+		}
+		var out sarifOutput
+		if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+			t.Fatalf("%s: Expected valid JSON, but got: %v\n%s", tc.Name, err, buf.String())
+		}
+
+		if out.Version != "2.1.0" || len(out.Runs) != 1 {
+			t.Fatalf("%s: Expected a single SARIF 2.1.0 run, got version %q with %d runs", tc.Name, out.Version, len(out.Runs))
+		}
+		run := out.Runs[0]
+		if run.Tool.Driver.Name != "git-diff-check" {
+			t.Errorf("%s: Unexpected driver name %q", tc.Name, run.Tool.Driver.Name)
+		}
+
+		// One reportingDescriptor per rule, whether loaded or not
+		rules := run.Tool.Driver.Rules
+		described := map[string]bool{}
+		for _, r := range rules {
+			if described[r.ID] {
+				t.Errorf("%s: Rule %s described more than once", tc.Name, r.ID)
+			}
+			described[r.ID] = true
+		}
+		ids := map[string]bool{}
+		for id := range loaded {
+			ids[id] = true
+		}
+		for _, e := range tc.Expected {
+			ids[e.RuleID] = true
+		}
+		if len(rules) != len(ids) {
+			t.Errorf("%s: Expected %d rules to be described, got %d", tc.Name, len(ids), len(rules))
+		}
+
+		if len(run.Results) != len(tc.Expected) {
+			t.Errorf("%s: Expected %d results, got %d", tc.Name, len(tc.Expected), len(run.Results))
+			continue
+		}
+		for i, e := range tc.Expected {
+			res := run.Results[i]
+			shouldEqual(tc.Name+" rule ID", res.RuleID, e.RuleID, t)
+			if res.RuleIndex < 0 || res.RuleIndex >= len(rules) || rules[res.RuleIndex].ID != res.RuleID {
+				t.Errorf("%s: Result %d for %s has a rule index %d that doesn't describe it", tc.Name, i, res.RuleID, res.RuleIndex)
+			}
+			shouldEqual(tc.Name+" level", res.Level, e.Level, t)
+
+			if len(res.Locations) != 1 {
+				t.Errorf("%s: Expected 1 location for result %d, got %d", tc.Name, i, len(res.Locations))
+				continue
+			}
+			location := res.Locations[0].PhysicalLocation
+			shouldEqual(tc.Name+" URI", location.ArtifactLocation.URI, e.URI, t)
+			switch {
+			case e.StartLine == 0 && location.Region != nil:
+				t.Errorf("%s: Expected no region for result %d, got %+v", tc.Name, i, *location.Region)
+			case e.StartLine != 0 && location.Region == nil:
+				t.Errorf("%s: Expected a region for result %d", tc.Name, i)
+			case e.StartLine != 0 && location.Region.StartLine != e.StartLine:
+				t.Errorf("%s: Expected result %d to start on line %d, got %d", tc.Name, i, e.StartLine, location.Region.StartLine)
+			}
+
+			if suppressed := len(res.Suppressions) > 0; suppressed != e.Suppressed {
+				t.Errorf("%s: Expected result %d suppressed to be %v, got %v", tc.Name, i, e.Suppressed, suppressed)
+			}
+			commit := ""
+			if res.Properties != nil {
+				commit = res.Properties.Commit
+			}
+			shouldEqual(tc.Name+" commit", commit, e.Commit, t)
+		}
+	}
+}
+
+func TestSARIFRuleSets(t *testing.T) {
+	detectors := diffcheck.Detectors
+	defer func() { diffcheck.Detectors = detectors }()
+
+	var tests = []struct {
+		Name      string
+		Detectors []diffcheck.Detector
+		Sets      []string
+	}{
+		{Name: "default", Sets: []string{"file", "line"}},
+		{Name: "pii", Detectors: []diffcheck.Detector{diffcheck.RuleSetDetector("pii")}, Sets: []string{"file", "line", "pii"}},
+	}
+
+	for _, tc := range tests {
+		diffcheck.Detectors = tc.Detectors
+
+		var buf bytes.Buffer
+		if err := writeSARIF(&buf, result{OK: true, Reports: []diffcheck.Report{}}); err != nil {
+			t.Fatalf("%s: Expected no error, but got: %v", tc.Name, err)
+		}
+		var out sarifOutput
+		if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+			t.Fatalf("%s: Expected valid JSON, but got: %v\n%s", tc.Name, err, buf.String())
+		}
+
+		described := map[string]bool{}
+		for _, r := range out.Runs[0].Tool.Driver.Rules {
+			described[r.ID] = true
+		}
+		expected := 0
+		for _, set := range tc.Sets {
+			for _, r := range rule.Sets[set] {
+				if !described[r.ID] {
+					t.Errorf("%s: Expected rule %s from the %s set to be described", tc.Name, r.ID, set)
+				}
+				expected++
+			}
+		}
+		if len(described) != expected {
+			t.Errorf("%s: Expected %d rules to be described, got %d", tc.Name, expected, len(described))
+		}
+	}
+}
+
+func TestSARIFWarning(t *testing.T) {
+	var tests = []struct {
+		Name       string
+		Warning    diffcheck.Warning
+		Region     *sarifRegion
+		Properties *sarifResultProps
+	}{
+		{
+			Name:    "line",
+			Warning: awsWarning,
+			Region:  &sarifRegion{StartLine: 3, EndLine: 3, StartColumn: 8, EndColumn: 28},
+		},
+		{
+			Name:    "several lines without columns",
+			Warning: diffcheck.Warning{Type: "line", RuleID: "private-key", Line: 4, EndLine: 9, Fingerprint: "f4"},
+			Region:  &sarifRegion{StartLine: 4, EndLine: 9},
+		},
+		{
+			Name:    "file",
+			Warning: keyFileWarning,
+		},
+		{
+			Name: "notebook cell",
+			Warning: diffcheck.Warning{
+				Type: "line", RuleID: "aws-access-key", Line: 44, EndLine: 44, Fingerprint: "f5",
+				Cell: &diffcheck.NotebookCell{Number: 3, Type: "code", Part: "source"}, Decoded: []string{"base64"},
+			},
+			Region:     &sarifRegion{StartLine: 44, EndLine: 44},
+			Properties: &sarifResultProps{Cell: "cell 3 code source", Decoded: []string{"base64"}},
+		},
+	}
+
+	for _, tc := range tests {
+		res := sarifWarning(diffcheck.Report{Path: "f"}, tc.Warning, 7)
+
+		shouldEqual(tc.Name+" rule ID", res.RuleID, tc.Warning.RuleID, t)
+		if res.RuleIndex != 7 {
+			t.Errorf("%s: Expected rule index 7, got %d", tc.Name, res.RuleIndex)
+		}
+		shouldEqual(tc.Name+" fingerprint", res.PartialFingerprints[sarifFingerprintKey], tc.Warning.Fingerprint, t)
+
+		region := res.Locations[0].PhysicalLocation.Region
+		switch {
+		case tc.Region == nil && region != nil:
+			t.Errorf("%s: Expected no region, got %+v", tc.Name, *region)
+		case tc.Region != nil && (region == nil || *region != *tc.Region):
+			t.Errorf("%s: Expected region %+v, got %+v", tc.Name, *tc.Region, region)
+		}
+
+		switch {
+		case tc.Properties == nil && res.Properties != nil:
+			t.Errorf("%s: Expected no properties, got %+v", tc.Name, *res.Properties)
+		case tc.Properties != nil && res.Properties == nil:
+			t.Errorf("%s: Expected properties %+v", tc.Name, *tc.Properties)
+		case tc.Properties != nil:
+			shouldEqual(tc.Name+" cell", res.Properties.Cell, tc.Properties.Cell, t)
+			shouldEqual(tc.Name+" decoded", fmt.Sprint(res.Properties.Decoded), fmt.Sprint(tc.Properties.Decoded), t)
+		}
+	}
+}
+
+func shouldEqual(field, got, expected string, t *testing.T) {
+	if got != expected {
+		t.Errorf("Expected %s to be %q, but got %q", field, expected, got)
+	}
+}
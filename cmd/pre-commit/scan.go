@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ONSdigital/git-diff-check/diffcheck"
+	"github.com/ONSdigital/git-diff-check/gitutil"
+)
+
+// runScan checks each commit in a range separately, e.g. to check a branch in
+// CI before it's merged. Fails if any commit in the range has a finding.
+func runScan(args []string) int {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	target := fs.String("p", ".", "(optional) path to repository")
+	revRange := fs.String("range", "", "range of commits to check, e.g. main..feature")
+	since := fs.String("since", "", "check the commits after the given ref, i.e. <ref>..HEAD")
+	addCheckFlags(fs)
+	fs.Parse(args)
+
+	spec := *revRange
+	switch {
+	case *revRange != "" && *since != "":
+		fmt.Fprintln(os.Stderr, "Only one of -range or -since may be given")
+		return rejected
+	case *since != "":
+		spec = *since + "..HEAD"
+	case spec == "":
+		fmt.Fprintln(os.Stderr, "One of -range or -since must be given")
+		return rejected
+	}
+
+	setupOutput()
+	cfg := configure(*target, rulePaths)
+
+	shas, err := gitutil.RevList(cfg.Root, spec)
+	if err != nil {
+		log.Fatal("Failed to list commits:", err)
+	}
+	fmt.Fprintf(info, "Running diff check on %d commits in %s\n", len(shas), spec)
+
+	return finish(checkCommits(*target, cfg.Root, shas))
+}
+
+// checkCommits checks each of the commits separately
+func checkCommits(target, dir string, shas []string) result {
+	r := result{Target: target, OK: true, Commits: []commitResult{}}
+	for _, sha := range shas {
+		c, err := checkCommit(dir, sha)
+		if err != nil {
+			log.Fatalf("Failed to check commit %s: %v", sha, err)
+		}
+		r.Commits = append(r.Commits, c)
+		r.OK = r.OK && c.OK
+	}
+	return r
+}
+
+// checkCommit runs the check against the changes introduced by a single
+// commit
+func checkCommit(dir, sha string) (commitResult, error) {
+	c, err := gitutil.Show(dir, sha)
+	if err != nil {
+		return commitResult{}, err
+	}
+	patch, err := gitutil.Patch(dir, sha)
+	if err != nil {
+		return commitResult{}, err
+	}
+	ok, reports, err := diffcheck.SnoopPatch(patch)
+	if err != nil {
+		return commitResult{}, err
+	}
+	return commitResult{Commit: c, OK: ok, Reports: reports}, nil
+}
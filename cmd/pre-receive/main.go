@@ -0,0 +1,265 @@
+// Command pre-receive is a server side git hook that rejects pushes containing
+// commits with potentially sensitive data. Unlike the client side hooks, it
+// can't be skipped with --no-verify.
+//
+// Install it as the pre-receive hook of a bare repository, or of every
+// repository on a server. git runs it in the repository with a
+// "<old sha> <new sha> <ref>" line on stdin for each ref being updated, and
+// shows anything it writes to stderr to the person pushing, prefixed with
+// "remote:".
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/git-diff-check/config"
+	"github.com/ONSdigital/git-diff-check/diffcheck"
+	"github.com/ONSdigital/git-diff-check/gitutil"
+	"github.com/ONSdigital/git-diff-check/ignore"
+)
+
+const (
+	// Return codes - a non-zero will cause git to reject the push
+	accepted = 0
+	rejected = 1
+)
+
+// Version is set at build time
+var Version string
+
+// listFlag collects the values of a flag that may be given more than once
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+var (
+	configPath  = flag.String("config", "", "(optional) server side config file, in the same format as .diffcheck.yml")
+	timeout     = flag.Duration("timeout", 30*time.Second, "maximum time to spend checking a push")
+	maxBytes    = flag.Int64("max-bytes", 20<<20, "maximum total size of the changes in a push to check")
+	maxCommits  = flag.Int("max-commits", 500, "maximum number of new commits in a push to check")
+	failOpen    = flag.Bool("fail-open", false, "accept pushes that are too large or slow to check, rather than rejecting them")
+	showVersion = flag.Bool("version", false, "show current version")
+	allowRefs   listFlag
+)
+
+func init() {
+	flag.Var(&allowRefs, "allow-ref", "(optional) ref pattern, e.g. refs/heads/fixtures/*, to accept without checking. May be repeated")
+}
+
+// budgetError is returned when a push is too large or slow to check
+type budgetError string
+
+func (e budgetError) Error() string {
+	return "push is too large to check - " + string(e)
+}
+
+// finding is a warning along with the commit and file it was found in
+type finding struct {
+	commit  gitutil.Commit
+	path    string
+	warning diffcheck.Warning
+}
+
+func main() {
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(Version)
+		os.Exit(accepted)
+	}
+
+	refs, err := gitutil.ParseReceiveRefs(os.Stdin)
+	if err != nil {
+		fail("Failed to read refs being pushed: %v", err)
+	}
+
+	scanner, err := configure()
+	if err != nil {
+		fail("Failed to configure diff check: %v", err)
+	}
+
+	// git commands still running when the deadline passes are killed
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	findings, err := check(ctx, scanner, refs)
+	cancel()
+
+	if _, ok := err.(budgetError); ok {
+		if *failOpen {
+			fmt.Fprintf(os.Stderr, "Diff check skipped: %v\n", err)
+			os.Exit(accepted)
+		}
+		fmt.Fprintf(os.Stderr, "Diff check rejected push: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Push fewer commits at a time, or ask an administrator to allow the ref")
+		os.Exit(rejected)
+	}
+	if err != nil {
+		fail("Failed to check push: %v", err)
+	}
+
+	if len(findings) == 0 {
+		os.Exit(accepted)
+	}
+	report(findings)
+	os.Exit(rejected)
+}
+
+// configure returns a scanner with the server side config. Config files in the
+// repository itself aren't used, as they could be changed by the push being
+// checked. For the same reason inline `diffcheck:allow` markers aren't
+// followed, and the repository's baseline file is checked like any other.
+func configure() (*diffcheck.Scanner, error) {
+	c := config.Default()
+	if *configPath != "" {
+		server, err := config.Read(*configPath)
+		if err != nil {
+			return nil, err
+		}
+		c.Merge(server)
+	}
+	opts, err := c.Options()
+	if err != nil {
+		return nil, err
+	}
+	opts.DisableSuppression = true
+
+	ignored := &ignore.List{}
+	for _, p := range c.Ignore {
+		// Already checked by Options
+		ignored.Add(p)
+	}
+	opts.Ignore = ignored
+
+	return diffcheck.New(opts), nil
+}
+
+// check finds the new commits for each ref and checks them, giving up once
+// the size budget is used up or ctx's deadline has passed
+func check(ctx context.Context, scanner *diffcheck.Scanner, refs []gitutil.ReceiveRef) ([]finding, error) {
+	findings, err := checkRefs(ctx, scanner, refs)
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, budgetError(fmt.Sprintf("took longer than %v", *timeout))
+	}
+	return findings, err
+}
+
+func checkRefs(ctx context.Context, scanner *diffcheck.Scanner, refs []gitutil.ReceiveRef) ([]finding, error) {
+	var shas []string
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		if allowed(ref.Ref) {
+			continue
+		}
+		incoming, err := gitutil.IncomingContext(ctx, ".", ref)
+		if err != nil {
+			return nil, err
+		}
+		for _, sha := range incoming {
+			if !seen[sha] {
+				seen[sha] = true
+				shas = append(shas, sha)
+			}
+		}
+	}
+	if len(shas) > *maxCommits {
+		return nil, budgetError(fmt.Sprintf("%d new commits, the limit is %d", len(shas), *maxCommits))
+	}
+
+	var (
+		findings []finding
+		size     int64
+	)
+	for _, sha := range shas {
+		c, err := gitutil.ShowContext(ctx, ".", sha)
+		if err != nil {
+			return nil, err
+		}
+		err = gitutil.StreamPatch(ctx, ".", sha, func(patch io.Reader) error {
+			_, err := scanner.Scan(ctx, &budgetReader{r: patch, size: &size}, diffcheck.ScanOptions{
+				Report: func(r diffcheck.Report) error {
+					for _, w := range r.Warnings {
+						findings = append(findings, finding{commit: c, path: r.Path, warning: w})
+					}
+					return nil
+				},
+			})
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return findings, nil
+}
+
+// budgetReader counts the bytes read from a commit's changes into the total
+// for the push, failing once it's over -max-bytes so that a huge commit is
+// never read in full
+type budgetReader struct {
+	r    io.Reader
+	size *int64
+}
+
+func (b *budgetReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	*b.size += int64(n)
+	if *b.size > *maxBytes {
+		return n, budgetError(fmt.Sprintf("changes are over the limit of %d bytes", *maxBytes))
+	}
+	return n, err
+}
+
+// allowed reports whether ref matches one of the -allow-ref patterns
+func allowed(ref string) bool {
+	for _, pattern := range allowRefs {
+		if ok, _ := path.Match(pattern, ref); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// report writes out the findings for the person pushing, grouped by commit
+func report(findings []finding) {
+	fmt.Fprintln(os.Stderr, "WARNING! Diff check rejected push - potential sensitive data found:")
+	last := ""
+	for _, f := range findings {
+		if f.commit.SHA != last {
+			fmt.Fprintf(os.Stderr, "Commit %s by %s: %s\n", f.commit.Short(), f.commit.Author, f.commit.Subject)
+			last = f.commit.SHA
+		}
+		where := ""
+		if f.warning.Cell != nil {
+			where = ", " + f.warning.Cell.String()
+		}
+		if len(f.warning.Decoded) > 0 {
+			where += ", decoded from " + strings.Join(f.warning.Decoded, " > ")
+		}
+		if f.warning.Type == "line" && f.warning.EndLine > f.warning.Line {
+			fmt.Fprintf(os.Stderr, "\t> (%s) %s (lines %d-%d%s, rule %s)\n", f.path, f.warning.Description, f.warning.Line, f.warning.EndLine, where, f.warning.RuleID)
+		} else if f.warning.Type == "line" {
+			fmt.Fprintf(os.Stderr, "\t> (%s) %s (line %d%s, rule %s)\n", f.path, f.warning.Description, f.warning.Line, where, f.warning.RuleID)
+		} else {
+			fmt.Fprintf(os.Stderr, "\t> (%s) %s (rule %s)\n", f.path, f.warning.Description, f.warning.RuleID)
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Remove the data from these commits, e.g. with git rebase -i, and push again")
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(rejected)
+}
@@ -0,0 +1,326 @@
+// Package config finds, reads and merges the settings files that control a
+// diff check.
+//
+// Settings are taken from, in increasing order of precedence:
+//
+//  1. the built in defaults (including the DC_ENTROPY_EXPERIMENT environment
+//     variable)
+//  2. the user-global config file, see GlobalPath
+//  3. the repository config file - the first of RepoFiles found at the top of
+//     the repository being checked
+//
+// Single valued settings such as `entropy`, and each of the `thresholds` and
+// detector `settings`, are overridden by the higher precedence file if it sets
+// them. List settings such as `rules` are combined, so a repository can add
+// to, but not remove from, the global lists.
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ONSdigital/git-diff-check/baseline"
+	"github.com/ONSdigital/git-diff-check/diffcheck"
+	"github.com/ONSdigital/git-diff-check/ignore"
+	"github.com/ONSdigital/git-diff-check/rule"
+)
+
+// Config represents the settings that can be given in a config file. The
+// file may be written as either YAML or JSON.
+type Config struct {
+	// Rules lists extra rule files, or directories of rule files, to load.
+	// Relative paths are resolved from the directory of the config file.
+	Rules []string `yaml:"rules"`
+
+	// Disable lists the IDs of built in or loaded rules, or the names of
+	// detectors, that shouldn't be run
+	Disable []string `yaml:"disable"`
+
+	// Detectors lists the names of optional detectors to run as well as the
+	// default ones. See diffcheck.RegisterOptional.
+	Detectors []string `yaml:"detectors"`
+
+	// Settings configures detectors, keyed by detector name, e.g.
+	// `data: {max_rows: 500}`. See diffcheck.ConfigurableDetector.
+	Settings map[string]interface{} `yaml:"settings"`
+
+	// Thresholds gives, by category, the number of findings of the category
+	// a file must have for any to be reported, e.g. `postcode: 5`
+	Thresholds map[string]int `yaml:"thresholds"`
+
+	// Entropy turns experimental entropy checking on or off
+	Entropy *bool `yaml:"entropy"`
+
+	// Ignore lists gitignore style patterns for files that shouldn't be
+	// scanned. These are applied before any patterns in the repository's
+	// IgnoreFile.
+	Ignore []string `yaml:"ignore"`
+
+	// Root is the top of the repository the config was loaded for
+	Root string `yaml:"-"`
+}
+
+// RepoFiles are the names of the repository config files, in the order they
+// are looked for. Only the first found is used.
+var RepoFiles = []string{".diffcheck.yml", ".diffcheck.yaml", ".diffcheck.json"}
+
+// IgnoreFile is the name of the file of ignore patterns at the top of a
+// repository. See package ignore for the syntax.
+const IgnoreFile = ".diffcheckignore"
+
+// Default returns the built in settings
+func Default() *Config {
+	entropy := os.Getenv("DC_ENTROPY_EXPERIMENT") == "1"
+	return &Config{Entropy: &entropy}
+}
+
+// GlobalPath returns the location of the user-global config file. This is
+// `git-diff-check/config` within $XDG_CONFIG_HOME, or ~/.config if that isn't
+// set.
+func GlobalPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "git-diff-check", "config"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "git-diff-check", "config"), nil
+}
+
+// FindRoot walks up from dir to find the top of the git repository it's in.
+// If dir isn't in a repository then dir itself is returned.
+func FindRoot(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for d := abs; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d, nil
+		}
+		if filepath.Dir(d) == d {
+			return abs, nil
+		}
+	}
+}
+
+// Load returns the merged settings for the repository containing dir
+func Load(dir string) (*Config, error) {
+	c := Default()
+
+	global, err := GlobalPath()
+	if err != nil {
+		return nil, err
+	}
+	g, err := readIfExists(global)
+	if err != nil {
+		return nil, err
+	}
+	c.Merge(g)
+
+	root, err := FindRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	c.Root = root
+	for _, name := range RepoFiles {
+		r, err := readIfExists(filepath.Join(root, name))
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			c.Merge(r)
+			break
+		}
+	}
+
+	return c, nil
+}
+
+// Read parses the config file at path
+func Read(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Config{}
+	if err := yaml.UnmarshalStrict(b, c); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	for i, p := range c.Rules {
+		if !filepath.IsAbs(p) {
+			c.Rules[i] = filepath.Join(filepath.Dir(path), p)
+		}
+	}
+
+	return c, nil
+}
+
+// readIfExists is Read, but returns a nil Config rather than an error if
+// there's no file at path
+func readIfExists(path string) (*Config, error) {
+	c, err := Read(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return c, err
+}
+
+// Merge overlays the settings from o on to c. Settings set in o take
+// precedence and lists are combined.
+func (c *Config) Merge(o *Config) {
+	if o == nil {
+		return
+	}
+	c.Rules = append(c.Rules, o.Rules...)
+	c.Disable = append(c.Disable, o.Disable...)
+	c.Detectors = append(c.Detectors, o.Detectors...)
+	for name, v := range o.Settings {
+		if c.Settings == nil {
+			c.Settings = make(map[string]interface{})
+		}
+		c.Settings[name] = v
+	}
+	for category, n := range o.Thresholds {
+		if c.Thresholds == nil {
+			c.Thresholds = make(map[string]int)
+		}
+		c.Thresholds[category] = n
+	}
+	c.Ignore = append(c.Ignore, o.Ignore...)
+	if o.Entropy != nil {
+		c.Entropy = o.Entropy
+	}
+}
+
+// Options returns the scanner options for the settings. The rules are the
+// current rule.Sets along with any rule files in the settings, without the
+// disabled rules - rule.Sets itself isn't changed. The detectors are the
+// default detectors and any optional ones named, without those disabled.
+func (c *Config) Options() (diffcheck.Options, error) {
+	opts := diffcheck.Options{Rules: rule.Copy(rule.Sets)}
+
+	for _, p := range c.Rules {
+		if err := rule.LoadPathInto(opts.Rules, p); err != nil {
+			return opts, fmt.Errorf("failed to load rules from %s:\n%v", p, err)
+		}
+	}
+
+	disabled := make(map[string]bool)
+	for _, id := range c.Disable {
+		disabled[id] = true
+	}
+	if len(disabled) > 0 {
+		for set, rules := range opts.Rules {
+			enabled := []rule.Rule{}
+			for _, r := range rules {
+				if !disabled[r.ID] {
+					enabled = append(enabled, r)
+				}
+			}
+			opts.Rules[set] = enabled
+		}
+	}
+
+	opts.Detectors = []diffcheck.Detector{}
+	added := make(map[string]bool)
+	for _, d := range diffcheck.DefaultDetectors() {
+		if !disabled[d.Name()] {
+			opts.Detectors = append(opts.Detectors, d)
+		}
+		added[d.Name()] = true
+	}
+	for _, name := range c.Detectors {
+		d, ok := diffcheck.Lookup(name)
+		if !ok {
+			return opts, fmt.Errorf("unknown detector %q", name)
+		}
+		if !disabled[name] && !added[name] {
+			opts.Detectors = append(opts.Detectors, d)
+		}
+		added[name] = true
+	}
+
+	if c.Entropy != nil {
+		opts.Entropy = *c.Entropy
+	}
+	opts.Thresholds = c.Thresholds
+
+	for name, v := range c.Settings {
+		if _, ok := diffcheck.Lookup(name); !ok {
+			return opts, fmt.Errorf("settings for unknown detector %q", name)
+		}
+		for i, d := range opts.Detectors {
+			if d.Name() != name {
+				continue
+			}
+			cd, ok := d.(diffcheck.ConfigurableDetector)
+			if !ok {
+				return opts, fmt.Errorf("detector %q has no settings", name)
+			}
+			configured, err := cd.Configure(decoder(v))
+			if err != nil {
+				return opts, fmt.Errorf("invalid settings for detector %q: %v", name, err)
+			}
+			opts.Detectors[i] = configured
+		}
+	}
+
+	// The baseline is made up of hashes which would otherwise trip the
+	// entropy check
+	ignored := &ignore.List{}
+	ignored.Add("/" + baseline.DefaultFile)
+	for _, p := range c.Ignore {
+		if err := ignored.Add(p); err != nil {
+			return opts, fmt.Errorf("invalid ignore pattern %q: %v", p, err)
+		}
+	}
+	if c.Root != "" {
+		l, err := ignore.ReadFile(filepath.Join(c.Root, IgnoreFile))
+		if err != nil {
+			return opts, err
+		}
+		ignored.Append(l)
+	}
+	opts.Ignore = ignored
+
+	return opts, nil
+}
+
+// decoder returns a function that decodes the settings v, as read from a
+// config file, into a detector's settings struct
+func decoder(v interface{}) func(interface{}) error {
+	return func(out interface{}) error {
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return yaml.UnmarshalStrict(b, out)
+	}
+}
+
+// Apply configures rule.Sets and the package level diffcheck settings with
+// the settings
+func (c *Config) Apply() error {
+	opts, err := c.Options()
+	if err != nil {
+		return err
+	}
+
+	rule.Sets = opts.Rules
+	if c.Entropy != nil {
+		diffcheck.UseEntropy = opts.Entropy
+	}
+	diffcheck.Ignore = opts.Ignore
+	diffcheck.Detectors = opts.Detectors
+	diffcheck.Thresholds = opts.Thresholds
+
+	return nil
+}
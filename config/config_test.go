@@ -0,0 +1,362 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/git-diff-check/baseline"
+	"github.com/ONSdigital/git-diff-check/config"
+	"github.com/ONSdigital/git-diff-check/diffcheck"
+	"github.com/ONSdigital/git-diff-check/ignore"
+	"github.com/ONSdigital/git-diff-check/rule"
+)
+
+// setup creates a fake repository, with a subdirectory, and a fake config home
+// directory. Returns the repository root, the config home and a cleanup func.
+func setup(t *testing.T) (string, string, func()) {
+	base, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := filepath.Join(base, "repo")
+	home := filepath.Join(base, "home")
+	for _, dir := range []string{
+		filepath.Join(repo, ".git"),
+		filepath.Join(repo, "sub", "dir"),
+		filepath.Join(home, "git-diff-check"),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldHome, oldEntropy := os.Getenv("XDG_CONFIG_HOME"), os.Getenv("DC_ENTROPY_EXPERIMENT")
+	os.Setenv("XDG_CONFIG_HOME", home)
+	os.Unsetenv("DC_ENTROPY_EXPERIMENT")
+
+	return repo, home, func() {
+		os.Setenv("XDG_CONFIG_HOME", oldHome)
+		os.Setenv("DC_ENTROPY_EXPERIMENT", oldEntropy)
+		os.RemoveAll(base)
+	}
+}
+
+func write(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindRoot(t *testing.T) {
+	repo, _, cleanup := setup(t)
+	defer cleanup()
+
+	root, err := config.FindRoot(filepath.Join(repo, "sub", "dir"))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if root != repo {
+		t.Errorf("Expected root %s, got %s", repo, root)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	repo, home, cleanup := setup(t)
+	defer cleanup()
+
+	global := filepath.Join(home, "git-diff-check", "config")
+
+	t.Log("Given no config files")
+	c, err := config.Load(repo)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if *c.Entropy {
+		t.Error("  Expected the default of entropy off")
+	}
+
+	t.Log("Given a global config file")
+	write(t, global, "entropy: true\nignore:\n  - vendor/\nrules:\n  - rules.json\n")
+	c, err = config.Load(repo)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !*c.Entropy {
+		t.Error("  Expected the global config to turn entropy on")
+	}
+	if expected := []string{filepath.Join(home, "git-diff-check", "rules.json")}; !reflect.DeepEqual(c.Rules, expected) {
+		t.Errorf("  Expected relative rule paths to be resolved to %v, got %v", expected, c.Rules)
+	}
+
+	t.Log("Given a repository config file as well")
+	write(t, filepath.Join(repo, ".diffcheck.json"), `{"entropy": false, "ignore": ["*.csv"]}`)
+	c, err = config.Load(filepath.Join(repo, "sub"))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if *c.Entropy {
+		t.Error("  Expected the repository config to turn entropy off")
+	}
+	if expected := []string{"vendor/", "*.csv"}; !reflect.DeepEqual(c.Ignore, expected) {
+		t.Errorf("  Expected ignore lists to be combined as %v, got %v", expected, c.Ignore)
+	}
+
+	t.Log("Given a repository YAML config file too")
+	write(t, filepath.Join(repo, ".diffcheck.yml"), "ignore: [docs/]\n")
+	c, err = config.Load(repo)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !*c.Entropy {
+		t.Error("  Expected only .diffcheck.yml to be used, leaving the global setting")
+	}
+	if expected := []string{"vendor/", "docs/"}; !reflect.DeepEqual(c.Ignore, expected) {
+		t.Errorf("  Expected ignore lists to be combined as %v, got %v", expected, c.Ignore)
+	}
+}
+
+func TestLoadEnvironmentDefault(t *testing.T) {
+	repo, _, cleanup := setup(t)
+	defer cleanup()
+
+	os.Setenv("DC_ENTROPY_EXPERIMENT", "1")
+
+	c, err := config.Load(repo)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !*c.Entropy {
+		t.Error("Expected DC_ENTROPY_EXPERIMENT to turn entropy on by default")
+	}
+
+	write(t, filepath.Join(repo, ".diffcheck.yml"), "entropy: false\n")
+	c, err = config.Load(repo)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if *c.Entropy {
+		t.Error("Expected repository config to override DC_ENTROPY_EXPERIMENT")
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	repo, _, cleanup := setup(t)
+	defer cleanup()
+
+	write(t, filepath.Join(repo, ".diffcheck.yml"), "entrophy: true\n")
+	if _, err := config.Load(repo); err == nil {
+		t.Error("Expected an error for an unknown setting")
+	}
+}
+
+func TestApplyIgnore(t *testing.T) {
+	repo, _, cleanup := setup(t)
+	defer cleanup()
+	defer func(saved *ignore.List) { diffcheck.Ignore = saved }(diffcheck.Ignore)
+
+	write(t, filepath.Join(repo, ".diffcheck.yml"), "ignore:\n  - \"*.csv\"\n  - fixtures/\n")
+	write(t, filepath.Join(repo, config.IgnoreFile), "!fixtures/keep.txt\nline:*.lock\n")
+
+	c, err := config.Load(repo)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if err := c.Apply(); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	for path, expected := range map[string]ignore.Scope{
+		"data/survey.csv":    ignore.All,
+		"fixtures/user.json": ignore.All,
+		"fixtures/keep.txt":  ignore.None,
+		"go.lock":            ignore.Line,
+		"main.go":            ignore.None,
+	} {
+		if got := diffcheck.Ignore.Match(path); got != expected {
+			t.Errorf("%s: expected %s, got %s", path, expected, got)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	repo, home, cleanup := setup(t)
+	defer cleanup()
+
+	write(t, filepath.Join(home, "git-diff-check", "config"), "thresholds:\n  postcode: 3\n  email: 2\n")
+	write(t, filepath.Join(repo, ".diffcheck.yml"), "disable:\n  - slack-token\n  - pii-email\nentropy: true\ndetectors:\n  - pii\nthresholds:\n  postcode: 5\n")
+
+	c, err := config.Load(repo)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	opts, err := c.Options()
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	has := func(sets map[string][]rule.Rule, id string) bool {
+		for _, r := range sets["line"] {
+			if r.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+	if has(opts.Rules, "slack-token") {
+		t.Error("Expected slack-token to be disabled in the options")
+	}
+	if !has(opts.Rules, "aws-access-key") {
+		t.Error("Expected aws-access-key to be enabled in the options")
+	}
+	if !has(rule.Sets, "slack-token") {
+		t.Error("Expected rule.Sets to be unchanged")
+	}
+	if !opts.Entropy {
+		t.Error("Expected entropy to be enabled")
+	}
+	if opts.Ignore.Match(baseline.DefaultFile) != ignore.All {
+		t.Error("Expected the baseline file to be ignored")
+	}
+
+	pii := false
+	for _, d := range opts.Detectors {
+		pii = pii || d.Name() == "pii"
+	}
+	if !pii {
+		t.Error("Expected the pii detector to be enabled")
+	}
+	for _, r := range opts.Rules["pii"] {
+		if r.ID == "pii-email" {
+			t.Error("Expected pii-email to be disabled in the options")
+		}
+	}
+	if !reflect.DeepEqual(opts.Thresholds, map[string]int{"postcode": 5, "email": 2}) {
+		t.Errorf("Expected the repository thresholds to override the global ones, got %v", opts.Thresholds)
+	}
+}
+
+// nopDetector is an optional detector for testing the detectors setting
+type nopDetector string
+
+func (d nopDetector) Name() string { return string(d) }
+
+func (nopDetector) Detect(*diffcheck.File, *diffcheck.Hunk) []diffcheck.Finding { return nil }
+
+func init() {
+	diffcheck.Register(nopDetector("config-test-default"))
+	diffcheck.RegisterOptional(nopDetector("config-test-optional"))
+}
+
+func TestOptionsDetectors(t *testing.T) {
+	names := func(ds []diffcheck.Detector) map[string]bool {
+		m := map[string]bool{}
+		for _, d := range ds {
+			m[d.Name()] = true
+		}
+		return m
+	}
+
+	var tests = []struct {
+		Name     string
+		Config   config.Config
+		Expected []string
+		Missing  []string
+		Err      bool
+	}{
+		{
+			Name:     "defaults",
+			Expected: []string{"config-test-default"},
+			Missing:  []string{"config-test-optional"},
+		},
+		{
+			Name:     "optional enabled",
+			Config:   config.Config{Detectors: []string{"config-test-optional", "config-test-optional"}},
+			Expected: []string{"config-test-default", "config-test-optional"},
+		},
+		{
+			Name:    "disabled",
+			Config:  config.Config{Detectors: []string{"config-test-optional"}, Disable: []string{"config-test-default", "config-test-optional"}},
+			Missing: []string{"config-test-default", "config-test-optional"},
+		},
+		{
+			Name:   "unknown",
+			Config: config.Config{Detectors: []string{"no-such-detector"}},
+			Err:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		opts, err := tc.Config.Options()
+		if tc.Err {
+			if err == nil {
+				t.Errorf("%s: Expected an error", tc.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Expected no error, but got: %v", tc.Name, err)
+			continue
+		}
+		got := names(opts.Detectors)
+		if len(opts.Detectors) != len(got) {
+			t.Errorf("%s: Expected each detector once, got %d detectors", tc.Name, len(opts.Detectors))
+		}
+		for _, n := range tc.Expected {
+			if !got[n] {
+				t.Errorf("%s: Expected detector %s", tc.Name, n)
+			}
+		}
+		for _, n := range tc.Missing {
+			if got[n] {
+				t.Errorf("%s: Expected no detector %s", tc.Name, n)
+			}
+		}
+	}
+}
+
+func TestOptionsSettings(t *testing.T) {
+	repo, _, cleanup := setup(t)
+	defer cleanup()
+
+	patch := []byte("diff --git a/a.csv b/a.csv\n--- /dev/null\n+++ b/a.csv\n@@ -0,0 +1,4 @@\n+id\n+1\n+2\n+3\n")
+
+	var tests = []struct {
+		Name   string
+		Config string
+		OK     bool
+		Err    bool
+	}{
+		{Name: "defaults", Config: "entropy: false\n", OK: true},
+		{Name: "configured", Config: "settings:\n  data:\n    max_rows: 2\n", OK: false},
+		{Name: "unknown detector", Config: "settings:\n  nope:\n    max_rows: 2\n", Err: true},
+		{Name: "detector without settings", Config: "settings:\n  config-test-default:\n    max_rows: 2\n", Err: true},
+		{Name: "unknown setting", Config: "settings:\n  data:\n    max_lines: 2\n", Err: true},
+	}
+
+	for _, tc := range tests {
+		write(t, filepath.Join(repo, ".diffcheck.yml"), tc.Config)
+		c, err := config.Load(repo)
+		if err != nil {
+			t.Fatalf("%s: Expected no error, but got: %v", tc.Name, err)
+		}
+		opts, err := c.Options()
+		if tc.Err {
+			if err == nil {
+				t.Errorf("%s: Expected an error", tc.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Expected no error, but got: %v", tc.Name, err)
+			continue
+		}
+		ok, _, err := diffcheck.New(opts).SnoopPatch(patch)
+		if err != nil || ok != tc.OK {
+			t.Errorf("%s: Expected ok to be %v, got %v (%v)", tc.Name, tc.OK, ok, err)
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package diffcheck
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ONSdigital/git-diff-check/rule"
+)
+
+// ConfigOptions configures the config file detector
+type ConfigOptions struct {
+	// Keys are extra words that mark a key as sensitive, in addition to
+	// ConfigKeys
+	Keys []string `yaml:"keys"`
+
+	// Placeholders are extra values that aren't reported, in addition to
+	// ConfigPlaceholders
+	Placeholders []string `yaml:"placeholders"`
+}
+
+// ConfigKeys are the words that mark a key as holding a secret. Keys are split
+// into words at `_`, `-`, `.`, spaces and changes of case, so `DB_PASSWORD`,
+// `dbPassword` and `db.password` all hold the word `password`. `api key`,
+// `access key`, `private key` and `secret key` also match as pairs of words.
+var ConfigKeys = []string{
+	"password", "passwd", "pwd", "pass", "passphrase",
+	"secret", "token", "apikey", "credential", "credentials",
+}
+
+// ConfigPlaceholders are values, or parts of values, that show a sensitive
+// key hasn't been given a real secret. They're compared ignoring case.
+var ConfigPlaceholders = []string{
+	"changeme", "change_me", "change-me", "replaceme", "replace_me", "replace-me",
+	"placeholder", "example", "dummy", "redacted", "xxxx", "****", "your_", "your-",
+}
+
+// configKeyPairs are pairs of words that mark a key as sensitive together
+var configKeyPairs = map[string]string{
+	"api": "key", "access": "key", "private": "key", "secret": "key", "client": "secret",
+}
+
+// configNotSecret are the last words of sensitive looking keys that name
+// something about a secret rather than the secret itself, e.g. `password_file`
+// or `token_ttl`
+var configNotSecret = map[string]bool{
+	"file": true, "path": true, "dir": true, "url": true, "uri": true, "endpoint": true,
+	"name": true, "id": true, "ref": true, "arn": true, "type": true, "policy": true,
+	"ttl": true, "expiry": true, "expires": true, "timeout": true, "length": true,
+	"min": true, "max": true, "rounds": true, "enabled": true, "required": true,
+	"header": true, "field": true, "param": true, "env": true, "var": true,
+}
+
+// configFormats maps the extensions of config files to their format
+var configFormats = map[string]string{
+	".yml":        "yaml",
+	".yaml":       "yaml",
+	".json":       "json",
+	".env":        "env",
+	".ini":        "ini",
+	".cfg":        "ini",
+	".conf":       "ini",
+	".toml":       "ini",
+	".properties": "ini",
+}
+
+const configRuleID = "config-secret"
+
+var (
+	reYAMLPair = regexp.MustCompile(`^\s*(?:-\s+)?("[^"]*"|'[^']*'|[^\s#'"{}\[\],:][^#{}\[\],:]*?)\s*:(?:\s+|$)`)
+	reJSONPair = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"\s*:\s*"((?:[^"\\]|\\.)*)"`)
+	reEnvPair  = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_.]*)\s*=`)
+	reINIPair  = regexp.MustCompile(`^\s*([^\s=:;#\[][^=:]*?)\s*[=:]`)
+
+	// Values filled in from elsewhere, e.g. `${DB_PASSWORD}`, `{{ .Token }}`,
+	// `<password>` or `%(secret)s`
+	reConfigReference = regexp.MustCompile(`\$\{|\{\{|^\$[A-Za-z_][A-Za-z0-9_]*$|^<.*>$|^%\(?[A-Za-z_]+\)?s?%?$|^#\{|^ENC\[|^vault:|^!`)
+)
+
+// ConfigDetector returns a Detector for YAML, JSON, .env, INI and properties
+// files that reports keys named like secrets, e.g. `password` or `api_key`,
+// whose values are literal, rather than placeholders or references such as
+// `changeme` or `${DB_PASSWORD}`
+func ConfigDetector(opts ConfigOptions) Detector {
+	d := configDetector{keys: map[string]bool{}, placeholders: ConfigPlaceholders}
+	for _, k := range append(ConfigKeys, opts.Keys...) {
+		d.keys[strings.ToLower(k)] = true
+	}
+	for _, p := range opts.Placeholders {
+		d.placeholders = append(d.placeholders, strings.ToLower(p))
+	}
+	d.opts = opts
+	return d
+}
+
+type configDetector struct {
+	opts         ConfigOptions
+	keys         map[string]bool
+	placeholders []string
+}
+
+func init() {
+	Register(ConfigDetector(ConfigOptions{}))
+}
+
+func (configDetector) Name() string { return "config" }
+
+func (d configDetector) Configure(decode func(v interface{}) error) (Detector, error) {
+	opts := d.opts
+	if err := decode(&opts); err != nil {
+		return nil, err
+	}
+	return ConfigDetector(opts), nil
+}
+
+func (d configDetector) Detect(file *File, hunk *Hunk) []Finding {
+	state := d.NewFile(file)
+	return append(state.Detect(hunk), state.End()...)
+}
+
+func (d configDetector) NewFile(file *File) FileState {
+	format := configFormat(file.Path)
+	if format == "" {
+		return noFileState{}
+	}
+	f := &configFile{config: d, format: format}
+	if format == "yaml" {
+		f.doc = newYAMLDocument()
+	}
+	return f
+}
+
+// configFile is the state of the config detector for a single file
+type configFile struct {
+	config configDetector
+	format string
+
+	// The YAML document being read, so that the values of SealedSecrets and
+	// Kubernetes Secrets (reported by the kubernetes detector) are skipped
+	doc *yamlDocument
+
+	// Findings in the data of a document whose kind isn't known yet, e.g.
+	// from `kubectl get -o yaml`, which sorts `data` before `kind`
+	pending []Finding
+}
+
+func (f *configFile) Detect(hunk *Hunk) []Finding {
+	var findings []Finding
+	for _, line := range hunk.Lines {
+		hold := false
+		if f.doc != nil {
+			count := f.doc.count
+			f.doc.line(line.Number, line.Text)
+			if f.doc.count != count {
+				// The last document's kind was never seen, so it wasn't a Secret
+				findings = append(findings, f.pending...)
+				f.pending = nil
+			}
+
+			switch f.doc.kind {
+			case "":
+			case "Secret", "SealedSecret":
+				f.pending = nil
+			default:
+				findings = append(findings, f.pending...)
+				f.pending = nil
+			}
+			if f.doc.sealed() || (f.doc.kind == "Secret" && f.doc.secretData()) {
+				continue
+			}
+			hold = f.doc.kind == "" && f.doc.secretData()
+		}
+
+		for _, p := range configPairs(f.format, line.Text) {
+			if !f.config.sensitive(p.key) || f.config.placeholder(p.key, p.value) {
+				continue
+			}
+			finding := Finding{
+				RuleID:      configRuleID,
+				Description: fmt.Sprintf("Possible secret in config key %q", p.key),
+				Severity:    rule.High,
+				Line:        line.Number,
+				Start:       p.start,
+				End:         p.end,
+			}
+			if hold {
+				f.pending = append(f.pending, finding)
+			} else {
+				findings = append(findings, finding)
+			}
+		}
+	}
+	return findings
+}
+
+// End reports the data of a last document whose kind was never seen, as it
+// wasn't a Secret
+func (f *configFile) End() []Finding {
+	findings := f.pending
+	f.pending = nil
+	return findings
+}
+
+// configFormat returns the format of the config file at path, or "" if it's
+// not a config file. `.env.local` and similar are also .env files.
+func configFormat(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	if name == ".env" || strings.HasPrefix(name, ".env.") {
+		return "env"
+	}
+	return configFormats[filepath.Ext(name)]
+}
+
+// configPair is a key and string value found in a line of a config file.
+// start and end are the offsets of the value, without quotes.
+type configPair struct {
+	key, value string
+	start, end int
+}
+
+// configPairs returns the key/value pairs in a line of a config file in the
+// given format. Only values on the same line as their key are found.
+func configPairs(format string, line []byte) []configPair {
+	var loc []int
+	switch format {
+	case "json":
+		var pairs []configPair
+		for _, m := range reJSONPair.FindAllSubmatchIndex(line, -1) {
+			pairs = append(pairs, configPair{
+				key:   string(line[m[2]:m[3]]),
+				value: string(line[m[4]:m[5]]),
+				start: m[4],
+				end:   m[5],
+			})
+		}
+		return pairs
+	case "yaml":
+		loc = reYAMLPair.FindSubmatchIndex(line)
+	case "env":
+		loc = reEnvPair.FindSubmatchIndex(line)
+	case "ini":
+		loc = reINIPair.FindSubmatchIndex(line)
+	}
+	if loc == nil {
+		return nil
+	}
+
+	key := strings.Trim(string(line[loc[2]:loc[3]]), `"'`)
+	start, end, ok := configValue(format, line, loc[1])
+	if !ok {
+		return nil
+	}
+	return []configPair{{key: key, value: string(line[start:end]), start: start, end: end}}
+}
+
+// configValue finds the value starting after offset in a line, removing
+// quotes, and comments after unquoted values
+func configValue(format string, line []byte, offset int) (start, end int, ok bool) {
+	start, end = offset, len(line)
+	for start < end && (line[start] == ' ' || line[start] == '\t') {
+		start++
+	}
+	if start == end {
+		return 0, 0, false
+	}
+
+	if q := line[start]; q == '"' || q == '\'' {
+		for i := start + 1; i < end; i++ {
+			if line[i] == '\\' && q == '"' {
+				i++
+				continue
+			}
+			if line[i] == q {
+				return start + 1, i, true
+			}
+		}
+		return 0, 0, false
+	}
+
+	comments := "#"
+	if format == "ini" {
+		comments = "#;"
+	}
+	for i := start; i < end; i++ {
+		if strings.IndexByte(comments, line[i]) >= 0 && (line[i-1] == ' ' || line[i-1] == '\t') {
+			end = i
+			break
+		}
+	}
+	for end > start && (line[end-1] == ' ' || line[end-1] == '\t' || line[end-1] == '\r') {
+		end--
+	}
+	if format == "yaml" && (line[start] == '|' || line[start] == '>' || line[start] == '&' || line[start] == '*') {
+		// Block scalars, anchors and aliases don't hold the value on this line
+		return 0, 0, false
+	}
+	return start, end, end > start
+}
+
+// sensitive reports whether a key is named like it holds a secret
+func (d configDetector) sensitive(key string) bool {
+	words := keyWords(key)
+	if len(words) == 0 || configNotSecret[words[len(words)-1]] {
+		return false
+	}
+	for i, w := range words {
+		if d.keys[w] {
+			return true
+		}
+		if i+1 < len(words) && configKeyPairs[w] == words[i+1] {
+			return true
+		}
+	}
+	return false
+}
+
+// placeholder reports whether the value of a sensitive key is a placeholder
+// or reference rather than a literal secret
+func (d configDetector) placeholder(key, value string) bool {
+	v := strings.ToLower(strings.TrimSpace(value))
+	switch v {
+	case "", "true", "false", "yes", "no", "on", "off", "null", "none", "nil", "~", "undefined":
+		return true
+	}
+	if reConfigReference.MatchString(value) {
+		return true
+	}
+	if strings.Trim(v, v[:1]) == "" || strings.IndexFunc(v, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+		// The same character repeated, or a number
+		return true
+	}
+	for _, w := range keyWords(key) {
+		if v == w {
+			// e.g. `password: password`
+			return true
+		}
+	}
+	for _, p := range d.placeholders {
+		if strings.Contains(v, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// keyWords splits a key into lower case words at separators and changes of
+// case, e.g. `dbPassword` and `DB_PASSWORD` are both `db password`
+func keyWords(key string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+	runes := []rune(key)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = nil
+		}
+	}
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			// dbPassword
+			flush()
+		case unicode.IsUpper(r) && i > 0 && unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			// APIKey
+			flush()
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
@@ -0,0 +1,118 @@
+package diffcheck_test
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/git-diff-check/diffcheck"
+	"github.com/ONSdigital/git-diff-check/rule"
+)
+
+func TestConfigDetector(t *testing.T) {
+	var tests = []struct {
+		Path    string
+		Line    string
+		Match   string // Empty if nothing should be found
+		Options diffcheck.ConfigOptions
+	}{
+		// YAML
+		{Path: "config.yml", Line: "password: hunter2", Match: "hunter2"},
+		{Path: "deploy/values.yaml", Line: `  dbPassword: "s3cr3t value" # prod`, Match: "s3cr3t value"},
+		{Path: "config.yml", Line: "  - api_key: 'k3y-9f8a7'", Match: "k3y-9f8a7"},
+		{Path: "config.yml", Line: "client_secret: abc123def # rotated", Match: "abc123def"},
+		{Path: "config.yml", Line: "password: ${DB_PASSWORD}"},
+		{Path: "config.yml", Line: "password: changeme"},
+		{Path: "config.yml", Line: "password: <your password>"},
+		{Path: "config.yml", Line: "token: '{{ .Values.token }}'"},
+		{Path: "config.yml", Line: "password: password"},
+		{Path: "config.yml", Line: "password: ''"},
+		{Path: "config.yml", Line: "password: |"},
+		{Path: "config.yml", Line: "password_file: /run/secrets/db"},
+		{Path: "config.yml", Line: "token_ttl: 3600"},
+		{Path: "config.yml", Line: "tokenizer: wordpiece"},
+		{Path: "config.yml", Line: "password: ENC[AES256_GCM,data:Tr7o=,iv:1=,tag:2=,type:str]"},
+		{Path: "config.yml", Line: "name: hunter2"},
+
+		// JSON
+		{Path: "appsettings.json", Line: `  "ApiKey": "9f8a7b6c5d4e",`, Match: "9f8a7b6c5d4e"},
+		{Path: "app.json", Line: `{"user": "app", "secretKey": "q9w8e7r6"}`, Match: "q9w8e7r6"},
+		{Path: "app.json", Line: `  "token": "$TOKEN"`},
+		{Path: "app.json", Line: `  "tokenUrl": "https://auth.example.com/token"`},
+
+		// .env
+		{Path: ".env", Line: "DB_PASS=hunter2", Match: "hunter2"},
+		{Path: "config/.env.production", Line: `export GITHUB_TOKEN="abc123def456"`, Match: "abc123def456"},
+		{Path: "prod.env", Line: "SECRET_KEY=s3cr3t # old", Match: "s3cr3t"},
+		{Path: ".env", Line: "DB_PASS="},
+		{Path: ".env.example", Line: "DB_PASS=xxxxxxxx"},
+		{Path: ".env", Line: "DB_USER=admin"},
+
+		// INI, TOML and properties
+		{Path: "setup.cfg", Line: "password = hunter2 ; dev", Match: "hunter2"},
+		{Path: "php.ini", Line: "mysql.default_password = \"s3cr3t\"", Match: "s3cr3t"},
+		{Path: "application.properties", Line: "spring.datasource.password=hunter2", Match: "hunter2"},
+		{Path: "config.toml", Line: `api_token = "t0k3n-value"`, Match: "t0k3n-value"},
+		{Path: "application.properties", Line: "spring.datasource.password=${DB_PASSWORD}"},
+		{Path: "config.ini", Line: "[password]"},
+		{Path: "config.ini", Line: "; password = hunter2"},
+
+		// Other files
+		{Path: "main.go", Line: "password: hunter2"},
+
+		// Options
+		{Path: "config.yml", Line: "pin: 1234abcd", Match: "1234abcd", Options: diffcheck.ConfigOptions{Keys: []string{"pin"}}},
+		{Path: "config.yml", Line: "password: notset", Options: diffcheck.ConfigOptions{Placeholders: []string{"NotSet"}}},
+	}
+
+	for _, tc := range tests {
+		d := diffcheck.ConfigDetector(tc.Options)
+		s := diffcheck.New(diffcheck.Options{Rules: map[string][]rule.Rule{}, Detectors: []diffcheck.Detector{d}, ShowMatches: true})
+
+		_, reports, err := s.SnoopPatch(dataPatch(tc.Path, []string{tc.Line}))
+		if err != nil {
+			t.Fatalf("%s: Expected no error, but got: %v", tc.Line, err)
+		}
+		var got []diffcheck.Warning
+		for _, r := range reports {
+			got = append(got, r.Warnings...)
+		}
+		if tc.Match == "" {
+			if len(got) > 0 {
+				t.Errorf("%s in %s: Expected no warnings, got %+v", tc.Line, tc.Path, got)
+			}
+			continue
+		}
+		if len(got) != 1 {
+			t.Errorf("%s in %s: Expected 1 warning, got %+v", tc.Line, tc.Path, got)
+			continue
+		}
+		shouldEqual("rule ID", got[0].RuleID, "config-secret", t)
+		shouldEqual("match", got[0].Match, tc.Match, t)
+	}
+}
+
+func TestConfigDetectorConfigure(t *testing.T) {
+	d, ok := diffcheck.Lookup("config")
+	if !ok {
+		t.Fatal("Expected the config detector to be registered")
+	}
+	cd, ok := d.(diffcheck.ConfigurableDetector)
+	if !ok {
+		t.Fatal("Expected the config detector to be configurable")
+	}
+	configured, err := cd.Configure(func(v interface{}) error {
+		v.(*diffcheck.ConfigOptions).Keys = []string{"pin"}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	s := diffcheck.New(diffcheck.Options{Detectors: []diffcheck.Detector{configured}})
+	ok, reports, err := s.SnoopPatch(dataPatch("config.yml", []string{"pin: 1234abcd", "password: hunter2"}))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if ok || len(reports) != 1 || len(reports[0].Warnings) != 2 {
+		t.Errorf("Expected the configured and built in keys to be found, got %+v", reports)
+	}
+}
@@ -0,0 +1,254 @@
+package diffcheck
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ONSdigital/git-diff-check/rule"
+)
+
+// DataOptions configures the data file detector
+type DataOptions struct {
+	// MaxRows is the number of rows that can be added to a data file before
+	// it's reported as a possible dataset. 0 turns the check off.
+	MaxRows int `yaml:"max_rows"`
+
+	// Columns are extra column names to report, in addition to
+	// DataColumns
+	Columns []string `yaml:"columns"`
+}
+
+// DataColumns are the names of columns that usually hold personal data. Names
+// are compared ignoring case, spaces, `_` and `-`, so `date_of_birth` also
+// matches `DateOfBirth`.
+var DataColumns = []string{
+	"nino", "ni_number", "national_insurance_number",
+	"nhs_number", "nhs_no",
+	"dob", "date_of_birth", "birth_date",
+	"postcode", "post_code", "home_address", "address_line_1", "uprn",
+	"first_name", "forename", "surname", "last_name", "full_name",
+	"email", "email_address", "phone", "phone_number", "telephone", "mobile",
+	"respondent_id", "person_id", "household_id",
+}
+
+// dataFormats maps the extensions of data files to the column delimiter, or
+// 0 for JSON lines
+var dataFormats = map[string]rune{
+	".csv":    ',',
+	".tsv":    '\t',
+	".jsonl":  0,
+	".ndjson": 0,
+}
+
+const (
+	dataColumnRuleID = "data-sensitive-column"
+	dataRowsRuleID   = "data-rows"
+
+	// maxDataObjects is the number of JSON lines objects checked for
+	// sensitive keys, as later ones usually repeat them
+	maxDataObjects = 100
+)
+
+// DataDetector returns a Detector for tabular data files - CSV, TSV and JSON
+// lines - that reports columns named like personal data, and files with more
+// added rows than opts.MaxRows
+func DataDetector(opts DataOptions) Detector {
+	return dataDetector{opts}
+}
+
+type dataDetector struct {
+	opts DataOptions
+}
+
+func init() {
+	Register(DataDetector(DataOptions{MaxRows: 1000}))
+}
+
+func (dataDetector) Name() string { return "data" }
+
+func (d dataDetector) Detect(file *File, hunk *Hunk) []Finding {
+	state := d.NewFile(file)
+	return append(state.Detect(hunk), state.End()...)
+}
+
+func (d dataDetector) Configure(decode func(v interface{}) error) (Detector, error) {
+	opts := d.opts
+	if err := decode(&opts); err != nil {
+		return nil, err
+	}
+	return dataDetector{opts}, nil
+}
+
+func (d dataDetector) NewFile(file *File) FileState {
+	delim, ok := dataFormats[strings.ToLower(filepath.Ext(file.Path))]
+	if !ok {
+		return noFileState{}
+	}
+
+	columns := map[string]bool{}
+	for _, c := range DataColumns {
+		columns[normaliseColumn(c)] = true
+	}
+	for _, c := range d.opts.Columns {
+		columns[normaliseColumn(c)] = true
+	}
+	return &dataFile{opts: d.opts, delim: delim, columns: columns, seen: map[string]bool{}}
+}
+
+// noFileState is the FileState for files a FileDetector doesn't check
+type noFileState struct{}
+
+func (noFileState) Detect(*Hunk) []Finding { return nil }
+func (noFileState) End() []Finding         { return nil }
+
+// dataFile is the state of the data detector for a single file
+type dataFile struct {
+	opts    DataOptions
+	delim   rune
+	columns map[string]bool
+
+	rows    int
+	first   int // Number of the first added row line
+	last    int // Number of the last added row line
+	objects int
+	seen    map[string]bool // Sensitive columns already reported
+}
+
+func (f *dataFile) Detect(hunk *Hunk) []Finding {
+	var findings []Finding
+	for _, line := range hunk.Lines {
+		if len(bytes.TrimSpace(line.Text)) == 0 {
+			continue
+		}
+		if f.delim == 0 {
+			f.row(line.Number)
+			if f.objects < maxDataObjects {
+				f.objects++
+				findings = append(findings, f.checkKeys(line)...)
+			}
+			continue
+		}
+		if line.Number == 1 {
+			// The header, which is only seen if it's been added or changed
+			findings = append(findings, f.checkHeader(line)...)
+			continue
+		}
+		f.row(line.Number)
+	}
+	return findings
+}
+
+// row counts an added row on the given line
+func (f *dataFile) row(number int) {
+	if f.rows == 0 {
+		f.first = number
+	}
+	f.rows++
+	f.last = number
+}
+
+// checkHeader reports the sensitive column names in a CSV or TSV header
+func (f *dataFile) checkHeader(line Line) []Finding {
+	r := csv.NewReader(bytes.NewReader(line.Text))
+	r.Comma = f.delim
+	r.LazyQuotes = true
+	names, err := r.Read()
+	if err != nil {
+		return nil
+	}
+
+	var findings []Finding
+	offset := 0
+	for _, name := range names {
+		start := offset + bytes.Index(line.Text[offset:], []byte(name))
+		if start < offset {
+			start = offset
+		}
+		if finding, ok := f.column(name, line.Number, start); ok {
+			findings = append(findings, finding)
+		}
+		offset = start + len(name)
+	}
+	return findings
+}
+
+// checkKeys reports the sensitive keys of a JSON lines object
+func (f *dataFile) checkKeys(line Line) []Finding {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(line.Text, &object); err != nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var findings []Finding
+	for _, key := range keys {
+		quoted, _ := json.Marshal(key)
+		start := bytes.Index(line.Text, quoted)
+		if start >= 0 {
+			start++
+		}
+		if finding, ok := f.column(key, line.Number, start); ok {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// column returns a finding for name if it's a sensitive column that hasn't
+// already been reported for the file. start is the offset of the name in the
+// line, or -1 if it's not known.
+func (f *dataFile) column(name string, line, start int) (Finding, bool) {
+	n := normaliseColumn(name)
+	if !f.columns[n] || f.seen[n] {
+		return Finding{}, false
+	}
+	f.seen[n] = true
+
+	finding := Finding{
+		RuleID:      dataColumnRuleID,
+		Description: fmt.Sprintf("Possible personal data column %q", name),
+		Severity:    rule.High,
+		Line:        line,
+		Match:       []byte(name),
+	}
+	if start >= 0 {
+		finding.Start, finding.End = start, start+len(name)
+	}
+	return finding, true
+}
+
+func (f *dataFile) End() []Finding {
+	if f.opts.MaxRows <= 0 || f.rows <= f.opts.MaxRows {
+		return nil
+	}
+	// The lines the rows were added on are part of the match, and so the
+	// fingerprint, so that a baselined addition doesn't hide later ones
+	return []Finding{{
+		RuleID:      dataRowsRuleID,
+		Description: fmt.Sprintf("Possible dataset - about %d rows added", f.rows),
+		Severity:    rule.Medium,
+		Match:       []byte(fmt.Sprintf("%d rows on lines %d-%d", f.rows, f.first, f.last)),
+	}}
+}
+
+// normaliseColumn returns the column name in lower case, without spaces, `_`
+// and `-`
+func normaliseColumn(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '_', '-':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(name)))
+}
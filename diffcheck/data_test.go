@@ -0,0 +1,179 @@
+package diffcheck_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/git-diff-check/baseline"
+	"github.com/ONSdigital/git-diff-check/diffcheck"
+)
+
+// dataPatch returns a patch adding a file at path with the given lines
+func dataPatch(path string, lines []string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n--- /dev/null\n+++ b/%s\n@@ -0,0 +1,%d @@\n", path, path, path, len(lines))
+	for _, l := range lines {
+		b.WriteString("+" + l + "\n")
+	}
+	return []byte(b.String())
+}
+
+// rows returns n lines of made up data
+func rows(n int, format string) []string {
+	var out []string
+	for i := 0; i < n; i++ {
+		out = append(out, fmt.Sprintf(format, i))
+	}
+	return out
+}
+
+func TestDataDetector(t *testing.T) {
+	var tests = []struct {
+		Name     string
+		Options  diffcheck.DataOptions
+		Path     string
+		Lines    []string
+		Expected []diffcheck.Warning
+	}{
+		{
+			Name:  "small example CSV",
+			Path:  "testdata/example.csv",
+			Lines: append([]string{"id,region,value"}, rows(3, "%d,north,1")...),
+		},
+		{
+			Name:  "sensitive CSV columns",
+			Path:  "data/extract.csv",
+			Lines: append([]string{`respondent_id,"Date Of Birth",region,NINO`}, rows(3, "%d,1980-01-01,north,AB123456C")...),
+			Expected: []diffcheck.Warning{
+				{Type: "line", RuleID: "data-sensitive-column", Line: 1, StartColumn: 1, EndColumn: 14, Match: "respondent_id"},
+				{Type: "line", RuleID: "data-sensitive-column", Line: 1, StartColumn: 16, EndColumn: 29, Match: "Date Of Birth"},
+				{Type: "line", RuleID: "data-sensitive-column", Line: 1, StartColumn: 38, EndColumn: 42, Match: "NINO"},
+			},
+		},
+		{
+			Name:  "sensitive TSV columns",
+			Path:  "data/extract.TSV",
+			Lines: []string{"id\tpostcode", "1\tNP10 8XG"},
+			Expected: []diffcheck.Warning{
+				{Type: "line", RuleID: "data-sensitive-column", Line: 1, StartColumn: 4, EndColumn: 12, Match: "postcode"},
+			},
+		},
+		{
+			Name:  "sensitive JSON lines keys",
+			Path:  "data/extract.jsonl",
+			Lines: []string{`{"id": 1, "nhs_number": "9434765919", "email": "a@b.uk"}`, `{"id": 2, "nhs_number": "4010232137"}`},
+			Expected: []diffcheck.Warning{
+				{Type: "line", RuleID: "data-sensitive-column", Line: 1, StartColumn: 40, EndColumn: 45, Match: "email"},
+				{Type: "line", RuleID: "data-sensitive-column", Line: 1, StartColumn: 12, EndColumn: 22, Match: "nhs_number"},
+			},
+		},
+		{
+			Name:  "many rows",
+			Path:  "data/survey.csv",
+			Lines: append([]string{"id,region,value"}, rows(1001, "%d,north,1")...),
+			Expected: []diffcheck.Warning{
+				{Type: "file", RuleID: "data-rows", Line: -1, Match: "1001 rows on lines 2-1002"},
+			},
+		},
+		{
+			Name:  "rows at the limit",
+			Path:  "data/survey.csv",
+			Lines: append([]string{"id,region,value"}, rows(1000, "%d,north,1")...),
+		},
+		{
+			Name:    "configured limit and columns",
+			Options: diffcheck.DataOptions{MaxRows: 10, Columns: []string{"case_ref"}},
+			Path:    "data/cases.csv",
+			Lines:   append([]string{"CaseRef,value"}, rows(11, "%d,1")...),
+			Expected: []diffcheck.Warning{
+				{Type: "line", RuleID: "data-sensitive-column", Line: 1, StartColumn: 1, EndColumn: 8, Match: "CaseRef"},
+				{Type: "file", RuleID: "data-rows", Line: -1, Match: "11 rows on lines 2-12"},
+			},
+		},
+		{
+			Name:  "other file types",
+			Path:  "notes.txt",
+			Lines: append([]string{"nino,dob"}, rows(2000, "%d")...),
+		},
+	}
+
+	for _, tc := range tests {
+		d := diffcheck.DataDetector(diffcheck.DataOptions{MaxRows: 1000})
+		if tc.Options.MaxRows > 0 {
+			d = diffcheck.DataDetector(tc.Options)
+		}
+		s := diffcheck.New(diffcheck.Options{Detectors: []diffcheck.Detector{d}, ShowMatches: true})
+
+		_, reports, err := s.SnoopPatch(dataPatch(tc.Path, tc.Lines))
+		if err != nil {
+			t.Fatalf("%s: Expected no error, but got: %v", tc.Name, err)
+		}
+		var got []diffcheck.Warning
+		for _, r := range reports {
+			got = append(got, r.Warnings...)
+		}
+		if len(got) != len(tc.Expected) {
+			t.Errorf("%s: Expected %d warnings, got %+v", tc.Name, len(tc.Expected), got)
+			continue
+		}
+		for i, e := range tc.Expected {
+			w := got[i]
+			shouldEqual("type", w.Type, e.Type, t)
+			shouldEqual("rule ID", w.RuleID, e.RuleID, t)
+			shouldEqualInt("line", w.Line, e.Line, t)
+			shouldEqualInt("start column", w.StartColumn, e.StartColumn, t)
+			shouldEqualInt("end column", w.EndColumn, e.EndColumn, t)
+			shouldEqual("match", w.Match, e.Match, t)
+		}
+	}
+}
+
+func TestDataDetectorBaseline(t *testing.T) {
+	d := diffcheck.DataDetector(diffcheck.DataOptions{MaxRows: 2})
+	s := diffcheck.New(diffcheck.Options{Detectors: []diffcheck.Detector{d}})
+	_, reports, err := s.SnoopPatch(dataPatch("data/survey.csv", rows(4, "%d,north")))
+	if err != nil || len(reports) != 1 {
+		t.Fatalf("Expected the rows to be reported, got %+v (%v)", reports, err)
+	}
+	known := baseline.New()
+	known.Add(baseline.Entry{Fingerprint: reports[0].Warnings[0].Fingerprint, Path: "data/survey.csv"})
+
+	// More rows added to the end of the same file
+	more := []byte("diff --git a/data/survey.csv b/data/survey.csv\n--- a/data/survey.csv\n+++ b/data/survey.csv\n@@ -4,0 +5,3 @@\n+4,south\n+5,south\n+6,south\n")
+	s = diffcheck.New(diffcheck.Options{Detectors: []diffcheck.Detector{d}, Baseline: known})
+	ok, reports, err := s.SnoopPatch(more)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if ok || len(reports) != 1 || reports[0].Warnings[0].RuleID != "data-rows" {
+		t.Errorf("Expected later rows to be reported despite the baseline, got %+v", reports)
+	}
+}
+
+func TestDataDetectorConfigure(t *testing.T) {
+	d, ok := diffcheck.Lookup("data")
+	if !ok {
+		t.Fatal("Expected the data detector to be registered")
+	}
+	cd, ok := d.(diffcheck.ConfigurableDetector)
+	if !ok {
+		t.Fatal("Expected the data detector to be configurable")
+	}
+	configured, err := cd.Configure(func(v interface{}) error {
+		v.(*diffcheck.DataOptions).MaxRows = 2
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	s := diffcheck.New(diffcheck.Options{Detectors: []diffcheck.Detector{configured}})
+	ok, reports, err := s.SnoopPatch(dataPatch("a.csv", rows(4, "%d")))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if ok || len(reports) != 1 || reports[0].Warnings[0].RuleID != "data-rows" {
+		t.Errorf("Expected the configured limit to be used, got %+v", reports)
+	}
+}
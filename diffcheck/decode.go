@@ -0,0 +1,192 @@
+package diffcheck
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ONSdigital/git-diff-check/rule"
+)
+
+// DecodeOptions configures the decoding detector
+type DecodeOptions struct {
+	// Depth is the number of layers of encoding looked through, e.g. 2 for
+	// base64 inside base64. 0 turns decoding off.
+	Depth int `yaml:"depth"`
+}
+
+const (
+	// maxDecodeDepth limits the depth that can be configured
+	maxDecodeDepth = 4
+
+	// maxDecodes is the most encoded strings decoded in each line, or
+	// decoded string, so that lines full of identifiers don't slow the check
+	// down
+	maxDecodes = 32
+)
+
+// decoders find and decode encoded strings. A decoder only returns text, as
+// the line rules can't match binary data.
+var decoders = []struct {
+	name   string
+	find   *regexp.Regexp
+	decode func([]byte) ([]byte, bool)
+}{
+	{"base64", regexp.MustCompile(`[A-Za-z0-9+/_-]{16,}={0,2}`), decodeBase64},
+	{"hex", regexp.MustCompile(`\b(?:[0-9A-Fa-f]{2}){12,}\b`), decodeHex},
+	{"url", regexp.MustCompile(`[^\s"'<>%]*(?:%[0-9A-Fa-f]{2}[^\s"'<>%]*)+`), decodeURL},
+}
+
+// DecodeDetector returns a Detector that finds base64, hex and URL encoded
+// strings in added lines and matches the line rules against the decoded
+// text. Each finding's Decoded lists the encodings it was found inside.
+func DecodeDetector(opts DecodeOptions) Detector {
+	if opts.Depth > maxDecodeDepth {
+		opts.Depth = maxDecodeDepth
+	}
+	return decodeDetector{opts: opts}
+}
+
+type decodeDetector struct {
+	opts  DecodeOptions
+	rules []rule.Rule // Set by New, otherwise rule.Sets["line"] is used
+}
+
+func init() {
+	Register(DecodeDetector(DecodeOptions{Depth: 2}))
+}
+
+func (decodeDetector) Name() string { return "decode" }
+
+func (d decodeDetector) Configure(decode func(v interface{}) error) (Detector, error) {
+	opts := d.opts
+	if err := decode(&opts); err != nil {
+		return nil, err
+	}
+	configured := DecodeDetector(opts).(decodeDetector)
+	configured.rules = d.rules
+	return configured, nil
+}
+
+func (d decodeDetector) withRules(sets map[string][]rule.Rule) Detector {
+	d.rules = sets["line"]
+	return d
+}
+
+func (d decodeDetector) Detect(file *File, hunk *Hunk) []Finding {
+	rules := d.rules
+	if rules == nil {
+		rules = rule.Sets["line"]
+	}
+	var line []rule.Rule
+	for _, r := range rules {
+		if r.Regex != nil && r.Scope != rule.HunkScope {
+			line = append(line, r)
+		}
+	}
+
+	var findings []Finding
+	for _, l := range hunk.Lines {
+		for _, f := range d.find(line, l.Text, d.opts.Depth, nil) {
+			f.Line = l.Number
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// find decodes the encoded strings in text and matches the rules against
+// them, looking through up to depth layers of encoding. Findings give the
+// offsets of the encoded string in text.
+func (d decodeDetector) find(rules []rule.Rule, text []byte, depth int, chain []string) []Finding {
+	if depth <= 0 {
+		return nil
+	}
+
+	var findings []Finding
+	decodes := 0
+	for _, dec := range decoders {
+		for _, loc := range dec.find.FindAllIndex(text, -1) {
+			if decodes >= maxDecodes {
+				return findings
+			}
+			encoded := text[loc[0]:loc[1]]
+			decoded, ok := dec.decode(encoded)
+			if !ok {
+				continue
+			}
+			decodes++
+			decodedChain := append(append([]string(nil), chain...), dec.name)
+
+			for _, r := range rules {
+				f, ok := match(r, decoded)
+				if !ok {
+					continue
+				}
+				if _, plain := match(r, encoded); plain {
+					// Already found without decoding
+					continue
+				}
+				f.Match = append([]byte(nil), decoded[f.Start:f.End]...)
+				f.Start, f.End = loc[0], loc[1]
+				f.Decoded = decodedChain
+				findings = append(findings, f)
+			}
+
+			for _, f := range d.find(rules, decoded, depth-1, decodedChain) {
+				f.Start, f.End = loc[0], loc[1]
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings
+}
+
+func decodeBase64(b []byte) ([]byte, bool) {
+	b = bytes.TrimRight(b, "=")
+	enc := base64.RawStdEncoding
+	if bytes.ContainsAny(b, "-_") {
+		if bytes.ContainsAny(b, "+/") {
+			return nil, false
+		}
+		enc = base64.RawURLEncoding
+	}
+	out, err := enc.DecodeString(string(b))
+	if err != nil {
+		return nil, false
+	}
+	return out, isText(out)
+}
+
+func decodeHex(b []byte) ([]byte, bool) {
+	out, err := hex.DecodeString(string(b))
+	if err != nil {
+		return nil, false
+	}
+	return out, isText(out)
+}
+
+func decodeURL(b []byte) ([]byte, bool) {
+	out, err := url.QueryUnescape(string(b))
+	if err != nil || out == string(b) {
+		return nil, false
+	}
+	return []byte(out), isText([]byte(out))
+}
+
+// isText reports whether b is printable UTF-8 text
+func isText(b []byte) bool {
+	if len(b) == 0 || !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,485 @@
+package diffcheck
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ONSdigital/git-diff-check/entropy"
+	"github.com/ONSdigital/git-diff-check/rule"
+)
+
+// maxHunkLines is the most added lines passed to detectors at once. Larger
+// hunks, e.g. new files, are passed in parts so that they don't all need to
+// be held in memory.
+const maxHunkLines = 1000
+
+// maxBlockBytes is the most text that hunk scope rules are matched against at
+// once. Longer runs of added lines are split into blocks of about this size.
+const maxBlockBytes = 64 << 10
+
+// maxOverlapLines and maxOverlapBytes limit the trailing lines of one part of
+// a hunk, or one block, that are carried into the next, so that hunk scope
+// rules can match across the split. They're enough for a PEM encoded 8192 bit
+// RSA key.
+const (
+	maxOverlapLines = 200
+	maxOverlapBytes = 16 << 10
+)
+
+type (
+	// File describes the file that a hunk belongs to
+	File struct {
+		// Current relative path of the file
+		Path string
+
+		// Old path of the file - will be identical unless the file has been
+		// moved/renamed as part of the changeset
+		OldPath string
+	}
+
+	// Line is a line added by a patch
+	Line struct {
+		// Line number in the new version of the file
+		Number int
+
+		// Text of the line, without the leading `+`
+		Text []byte
+	}
+
+	// Hunk holds the lines added by a hunk of a patch, in order. Very large
+	// hunks are passed to detectors in parts.
+	Hunk struct {
+		Lines []Line
+
+		// The trailing lines of the previous part of a large hunk, which
+		// have already been passed to detectors. Only hunk scope rules use
+		// them.
+		carried []Line
+
+		// Decoded notebook lines, by line number
+		views map[int]*notebookLine
+	}
+
+	// Finding is a match found by a Detector
+	Finding struct {
+		// ID of the rule that was matched, used to allow, disable and
+		// baseline the finding
+		RuleID string
+
+		// Human compatible description, e.g. "Possible AWS Access Key"
+		Description string
+
+		// Severity of the finding, see rule.Severities. Defaults to medium.
+		Severity string
+
+		// Kind of data found, if the finding is counted towards a
+		// threshold, e.g. "postcode". See Options.Thresholds.
+		Category string
+
+		// Line number of the match
+		Line int
+
+		// Line number of the end of a match that spans several lines. 0
+		// if the match is on a single line.
+		EndLine int
+
+		// Byte offsets of the match in the line's text. End is the offset
+		// after the last matched byte, in the text of EndLine if set.
+		Start, End int
+
+		// The text that was matched, if not the text between Start and End,
+		// e.g. when a match is made up of several parts or lines
+		Match []byte
+
+		// Encodings the match was found inside, outermost first, e.g.
+		// ["base64"] if the text between Start and End is base64 encoded
+		Decoded []string
+	}
+
+	// Detector checks the lines added to a file for potentially sensitive
+	// information. Detectors must be safe to call from many goroutines at
+	// once.
+	Detector interface {
+		// Name identifies the detector, e.g. to enable or disable it in
+		// config
+		Name() string
+
+		// Detect returns the matches found in the hunk
+		Detect(file *File, hunk *Hunk) []Finding
+	}
+
+	// FileDetector is implemented by detectors that need to see all of a
+	// file's added lines before reporting on them, e.g. to count them. A
+	// Scanner calls NewFile at the start of each file, passes the file's
+	// hunks to the returned FileState in place of calling Detect, then calls
+	// End.
+	FileDetector interface {
+		Detector
+		NewFile(file *File) FileState
+	}
+
+	// FileState checks the hunks of a single file for a FileDetector. It's
+	// only used by one goroutine at a time.
+	FileState interface {
+		// Detect returns the matches found in the hunk
+		Detect(hunk *Hunk) []Finding
+
+		// End returns findings about the file as a whole, once all of its
+		// hunks have been checked. Findings with no Line are reported
+		// against the file rather than a line, and any others should give
+		// the Match, as the line's text is no longer available.
+		End() []Finding
+	}
+
+	// ConfigurableDetector is implemented by detectors with settings, e.g.
+	// from the `settings` config. Configure returns a copy of the detector
+	// with the settings decoded into v by decode, in the same way as
+	// yaml.Unmarshal.
+	ConfigurableDetector interface {
+		Detector
+		Configure(decode func(v interface{}) error) (Detector, error)
+	}
+
+	// LineDetector checks a single added line at a time. Use PerLine to turn
+	// it into a Detector.
+	LineDetector interface {
+		Name() string
+
+		// DetectLine returns the matches found in the line. The Line of
+		// each finding is filled in by PerLine.
+		DetectLine(file *File, line Line) []Finding
+	}
+)
+
+// PerLine returns a Detector that runs d on each line of a hunk
+func PerLine(d LineDetector) Detector {
+	return perLine{d}
+}
+
+type perLine struct {
+	LineDetector
+}
+
+func (p perLine) Detect(file *File, hunk *Hunk) []Finding {
+	var findings []Finding
+	for _, line := range hunk.Lines {
+		for _, f := range p.DetectLine(file, line) {
+			f.Line = line.Number
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// RuleDetector returns a Detector that matches each of the regex line rules
+// against every added line, or against runs of consecutive added lines for
+// rules with rule.HunkScope
+func RuleDetector(rules []rule.Rule) Detector {
+	var d ruleDetector
+	for _, r := range rules {
+		switch {
+		case r.Regex == nil:
+		case r.Scope == rule.HunkScope:
+			d.hunk = append(d.hunk, r)
+		default:
+			d.line = append(d.line, r)
+		}
+	}
+	return d
+}
+
+type ruleDetector struct {
+	line, hunk []rule.Rule
+}
+
+func (ruleDetector) Name() string { return "rules" }
+
+func (d ruleDetector) Detect(file *File, hunk *Hunk) []Finding {
+	var findings []Finding
+	for _, line := range hunk.Lines {
+		for _, r := range d.line {
+			if f, ok := match(r, line.Text); ok {
+				f.Line = line.Number
+				findings = append(findings, f)
+			}
+		}
+	}
+	if len(d.hunk) == 0 {
+		return findings
+	}
+	for _, b := range blocks(hunk) {
+		for _, r := range d.hunk {
+			if f, ok := match(r, b.text); ok {
+				if f = b.locate(f); !b.matched(f) {
+					findings = append(findings, f)
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// ruleUser is implemented by detectors that use the rules a Scanner is
+// configured with. New passes them the rules from Options.Rules.
+type ruleUser interface {
+	withRules(sets map[string][]rule.Rule) Detector
+}
+
+// RuleSetDetector returns a Detector, named after the ruleset, that runs a
+// ruleset other than "line" in the same way as RuleDetector. A Scanner runs
+// the ruleset from its Options.Rules, so rules can be disabled by ID.
+func RuleSetDetector(set string) Detector {
+	return ruleSetDetector{set: set}
+}
+
+type ruleSetDetector struct {
+	set   string
+	rules Detector // Set by New, otherwise the ruleset in rule.Sets is used
+}
+
+func (d ruleSetDetector) Name() string { return d.set }
+
+func (d ruleSetDetector) withRules(sets map[string][]rule.Rule) Detector {
+	d.rules = RuleDetector(sets[d.set])
+	return d
+}
+
+func (d ruleSetDetector) Detect(file *File, hunk *Hunk) []Finding {
+	if d.rules == nil {
+		return RuleDetector(rule.Sets[d.set]).Detect(file, hunk)
+	}
+	return d.rules.Detect(file, hunk)
+}
+
+func init() {
+	// UK personal data, see rule.Sets["pii"]
+	RegisterOptional(RuleSetDetector("pii"))
+}
+
+// block is a run of consecutive added lines joined by newlines
+type block struct {
+	text    []byte
+	offsets []int  // Offset of each line in text
+	lines   []Line // The lines joined
+	seen    int    // Number of lines at the start already matched before
+}
+
+// blocks splits the hunk into runs of consecutive lines, each no longer than
+// maxBlockBytes unless it's a single line. Each block split by size starts
+// with the end of the block before, and the first starts with the lines
+// carried from the previous part of the hunk.
+func blocks(hunk *Hunk) []block {
+	var bs []block
+	cur := newBlock(hunk.carried)
+	for _, l := range hunk.Lines {
+		n := len(cur.lines)
+		switch {
+		case n > 0 && l.Number != cur.lines[n-1].Number+1:
+			bs = cur.appendTo(bs)
+			cur = block{}
+		case n > 0 && len(cur.text)+len(l.Text) >= maxBlockBytes:
+			bs = cur.appendTo(bs)
+			cur = newBlock(overlap(cur.lines))
+		}
+		cur.add(l)
+	}
+	return cur.appendTo(bs)
+}
+
+// newBlock returns a block starting with lines that have already been
+// matched as part of the block before
+func newBlock(seen []Line) block {
+	var b block
+	for _, l := range seen {
+		b.add(l)
+	}
+	b.seen = len(seen)
+	return b
+}
+
+func (b *block) add(l Line) {
+	if len(b.lines) > 0 {
+		b.text = append(b.text, '\n')
+	}
+	b.offsets = append(b.offsets, len(b.text))
+	b.lines = append(b.lines, l)
+	b.text = append(b.text, l.Text...)
+}
+
+// appendTo appends the block to bs if it has any lines that haven't already
+// been matched
+func (b block) appendTo(bs []block) []block {
+	if len(b.lines) > b.seen {
+		bs = append(bs, b)
+	}
+	return bs
+}
+
+// matched reports whether a located finding is within the lines already
+// matched as part of the block before, so has already been reported
+func (b block) matched(f Finding) bool {
+	return b.seen > 0 && f.EndLine <= b.lines[b.seen-1].Number
+}
+
+// overlap returns the trailing lines to carry into the next part of a hunk or
+// the next block, within maxOverlapLines and maxOverlapBytes
+func overlap(lines []Line) []Line {
+	i, size := len(lines), 0
+	for i > 0 && len(lines)-i < maxOverlapLines && size+len(lines[i-1].Text) < maxOverlapBytes {
+		i--
+		size += len(lines[i].Text) + 1
+	}
+	return lines[i:]
+}
+
+// position returns the line number and offset within the line of an offset
+// in the block's text
+func (b block) position(offset int) (int, int) {
+	i := sort.SearchInts(b.offsets, offset+1) - 1
+	return b.lines[i].Number, offset - b.offsets[i]
+}
+
+// locate turns a finding with offsets in the block's text into one with line
+// numbers and offsets within those lines
+func (b block) locate(f Finding) Finding {
+	// A match ending in a newline ends on the line before
+	for f.End > f.Start && b.text[f.End-1] == '\n' {
+		f.End--
+	}
+	f.Match = append([]byte(nil), b.text[f.Start:f.End]...)
+
+	start, end := f.Start, f.End
+	f.Line, f.Start = b.position(start)
+	f.EndLine, f.End = f.Line, f.Start
+	if end > start {
+		// End is the offset after the last matched byte
+		f.EndLine, f.End = b.position(end - 1)
+		f.End++
+	}
+	if f.EndLine == f.Line {
+		f.EndLine = 0
+	}
+	return f
+}
+
+// match returns the first match of r in text that passes the rule's
+// validator. If there are matches but none are valid, the first is returned
+// with Low severity if the rule downgrades invalid matches.
+func match(r rule.Rule, text []byte) (Finding, bool) {
+	locs := r.Regex.FindAllIndex(text, -1)
+	if len(locs) == 0 {
+		return Finding{}, false
+	}
+
+	f := Finding{RuleID: r.ID, Description: r.Caption, Severity: r.Severity, Category: r.Category}
+	for _, loc := range locs {
+		if r.Valid(text[loc[0]:loc[1]]) {
+			f.Start, f.End = loc[0], loc[1]
+			return f, true
+		}
+	}
+	if r.Invalid != rule.Downgrade {
+		return Finding{}, false
+	}
+	f.Severity = rule.Low
+	f.Start, f.End = locs[0][0], locs[0][1]
+	return f, true
+}
+
+// EntropyDetector returns a Detector for the experimental check for strings
+// with high entropy, such as keys, see package entropy
+func EntropyDetector() Detector {
+	return PerLine(entropyDetector{})
+}
+
+type entropyDetector struct{}
+
+func (entropyDetector) Name() string { return "entropy" }
+
+func (entropyDetector) DetectLine(file *File, line Line) []Finding {
+	found := entropy.Find(line.Text)
+	if len(found) == 0 {
+		return nil
+	}
+	start := bytes.Index(line.Text, found[0])
+	return []Finding{{
+		RuleID:      entropyRuleID,
+		Description: entropyCaption,
+		Severity:    rule.Medium,
+		Start:       start,
+		End:         start + len(found[0]),
+		Match:       bytes.Join(found, []byte(" ")),
+	}}
+}
+
+// registration is a registered detector, and whether it runs by default
+type registration struct {
+	detector Detector
+	enabled  bool
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]registration{}
+)
+
+// Register makes a detector available by name, and runs it by default. It's
+// intended to be called from the init function of the package providing the
+// detector, so that organisation specific detectors can be compiled in. Panics
+// if a detector with the same name is already registered.
+func Register(d Detector) {
+	register(d, true)
+}
+
+// RegisterOptional makes a detector available by name, but only runs it when
+// enabled, e.g. with the `detectors` config setting. Panics if a detector with
+// the same name is already registered.
+func RegisterOptional(d Detector) {
+	register(d, false)
+}
+
+func register(d Detector, enabled bool) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	name := d.Name()
+	if name == "" {
+		panic("diffcheck: detector has no name")
+	}
+	if _, dup := registry[name]; dup {
+		panic(fmt.Sprintf("diffcheck: detector %q registered twice", name))
+	}
+	registry[name] = registration{detector: d, enabled: enabled}
+}
+
+// Lookup returns the registered detector with the given name
+func Lookup(name string) (Detector, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	r, ok := registry[name]
+	return r.detector, ok
+}
+
+// DefaultDetectors returns the registered detectors that run by default, in
+// name order
+func DefaultDetectors() []Detector {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name, r := range registry {
+		if r.enabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	detectors := make([]Detector, 0, len(names))
+	for _, name := range names {
+		detectors = append(detectors, registry[name].detector)
+	}
+	return detectors
+}
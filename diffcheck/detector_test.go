@@ -0,0 +1,122 @@
+package diffcheck_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ONSdigital/git-diff-check/diffcheck"
+	"github.com/ONSdigital/git-diff-check/rule"
+)
+
+// labelDetector finds a value on the line after a "password:" label, which a
+// single line rule can't do
+type labelDetector struct{}
+
+func (labelDetector) Name() string { return "test-label" }
+
+func (labelDetector) Detect(file *diffcheck.File, hunk *diffcheck.Hunk) []diffcheck.Finding {
+	var findings []diffcheck.Finding
+	for i := 1; i < len(hunk.Lines); i++ {
+		prev, line := hunk.Lines[i-1], hunk.Lines[i]
+		if bytes.HasSuffix(bytes.TrimSpace(prev.Text), []byte("password:")) && line.Number == prev.Number+1 {
+			value := bytes.TrimSpace(line.Text)
+			start := bytes.Index(line.Text, value)
+			findings = append(findings, diffcheck.Finding{
+				RuleID:      "test-labelled-password",
+				Description: "Password on the line after its label",
+				Severity:    rule.High,
+				Line:        line.Number,
+				Start:       start,
+				End:         start + len(value),
+			})
+		}
+	}
+	return findings
+}
+
+// wordDetector finds a word on each line
+type wordDetector struct{}
+
+func (wordDetector) Name() string { return "test-word" }
+
+func (wordDetector) DetectLine(file *diffcheck.File, line diffcheck.Line) []diffcheck.Finding {
+	if i := bytes.Index(line.Text, []byte("hunter2")); i >= 0 {
+		return []diffcheck.Finding{{RuleID: "test-word", Description: "Famous password", Start: i, End: i + 7}}
+	}
+	return nil
+}
+
+func init() {
+	diffcheck.RegisterOptional(labelDetector{})
+	diffcheck.RegisterOptional(diffcheck.PerLine(wordDetector{}))
+}
+
+func TestDetectors(t *testing.T) {
+	patch := []byte(`diff --git a/config.yml b/config.yml
+index e69de29..92251f8 100644
+--- a/config.yml
++++ b/config.yml
+@@ -0,0 +1,4 @@
++user: admin
++password:
++  correcthorsebatterystaple
++other: hunter2 # diffcheck:allow rule=test-word
+`)
+
+	label, ok := diffcheck.Lookup("test-label")
+	if !ok {
+		t.Fatal("Expected test-label to be registered")
+	}
+	word, ok := diffcheck.Lookup("test-word")
+	if !ok {
+		t.Fatal("Expected test-word to be registered")
+	}
+	for _, d := range diffcheck.DefaultDetectors() {
+		if d.Name() == "test-label" || d.Name() == "test-word" {
+			t.Errorf("Expected optional detector %s not to run by default", d.Name())
+		}
+	}
+
+	// Optional detectors don't run unless asked for
+	_, reports, err := diffcheck.New(diffcheck.Options{}).SnoopPatch(patch)
+	if err != nil || len(reports) != 0 {
+		t.Errorf("Expected nothing to be found without the detectors, got %+v (%v)", reports, err)
+	}
+
+	s := diffcheck.New(diffcheck.Options{Detectors: []diffcheck.Detector{label, word}})
+	ok, reports, err = s.SnoopPatch(patch)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if ok || len(reports) != 1 {
+		t.Fatalf("Expected a single failing report, got %v %+v", ok, reports)
+	}
+
+	r := reports[0]
+	if len(r.Warnings) != 1 || len(r.Suppressed) != 1 {
+		t.Fatalf("Expected 1 warning and 1 suppressed warning, got %+v", r)
+	}
+	w := r.Warnings[0]
+	shouldEqual("rule ID", w.RuleID, "test-labelled-password", t)
+	shouldEqual("severity", w.Severity, rule.High, t)
+	shouldEqualInt("line", w.Line, 3, t)
+	shouldEqualInt("start column", w.StartColumn, 3, t)
+	shouldEqualInt("end column", w.EndColumn, 28, t)
+	if w.Fingerprint == "" {
+		t.Error("Expected the warning to be fingerprinted")
+	}
+
+	sup := r.Suppressed[0]
+	shouldEqual("rule ID", sup.RuleID, "test-word", t)
+	shouldEqual("severity", sup.Severity, rule.Medium, t)
+	shouldEqualInt("line", sup.Line, 4, t)
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a detector twice to panic")
+		}
+	}()
+	diffcheck.Register(labelDetector{})
+}
@@ -5,13 +5,17 @@ package diffcheck
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"fmt"
 	"io"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
-	"github.com/ONSdigital/git-diff-check/entropy"
+	"github.com/ONSdigital/git-diff-check/baseline"
+	"github.com/ONSdigital/git-diff-check/ignore"
 	"github.com/ONSdigital/git-diff-check/rule"
 )
 
@@ -28,6 +32,42 @@ type (
 		// Line number (if applicable) where the warning was triggered.
 		// If no line then will be -1
 		Line int
+
+		// Last line of the text that triggered the warning, which is the
+		// same as Line unless the match spans several lines
+		EndLine int
+
+		// ID of the rule that triggered the warning
+		RuleID string
+
+		// Severity of the rule that triggered the warning, see rule.Severities
+		Severity string
+
+		// Kind of data found, e.g. "postcode" for personal data, or empty
+		Category string
+
+		// Cell (if known) of a Jupyter notebook the warning was found in
+		Cell *NotebookCell
+
+		// Encodings the matched text was found inside, outermost first, e.g.
+		// ["base64", "url"]. The columns are of the encoded text.
+		Decoded []string
+
+		// Byte columns (if applicable) of the text that triggered the
+		// warning, counting from 1. EndColumn is the column after the last
+		// matched byte on EndLine. If no columns then both will be 0.
+		StartColumn int
+		EndColumn   int
+
+		// The text that triggered the warning. Only the first few
+		// characters are shown unless ShowMatches is set.
+		Match string
+
+		// Identifies the finding in a baseline, see package baseline
+		Fingerprint string
+
+		// The text that triggered the warning
+		match []byte
 	}
 
 	// Report is a collection of warnings for a particular file discovered in
@@ -42,6 +82,15 @@ type (
 
 		// Set of warnings pertaining to this report
 		Warnings []Warning
+
+		// Set of warnings that would have been raised but were suppressed
+		// with an inline `diffcheck:allow` marker on the line. These don't
+		// cause the patch to fail.
+		Suppressed []Warning
+
+		// The rulesets that were skipped for the file because it matched
+		// the Ignore list
+		Ignored ignore.Scope
 	}
 )
 
@@ -49,25 +98,143 @@ var (
 	// Matches the first offset in the old and new diff
 	reOffset = regexp.MustCompile("^@@ -(\\d+).* \\+(\\d+).* @@")
 
+	// Matches an inline suppression marker, optionally restricted to a comma
+	// separated list of rules
+	reAllow = regexp.MustCompile(`diffcheck:allow(?:\s+rule=([\w.,-]+))?`)
+
+	// The settings below configure the package level SnoopPatch and Scan. A
+	// Scanner should be used instead where more than one configuration is
+	// needed, or the settings may change while a scan is running.
+
 	// UseEntropy is a feature flag that, if set true, enables experimental
 	// string entropy testing
 	UseEntropy = false
+
+	// Ignore is a list of patterns for files that shouldn't be scanned, or
+	// should only be scanned with some of the rulesets
+	Ignore *ignore.List
+
+	// Baseline is a set of known findings which won't be reported
+	Baseline *baseline.Baseline
+
+	// ShowMatches, if set true, includes the full matched text in each
+	// Warning rather than a redacted version
+	ShowMatches = false
+
+	// Detectors to run in addition to the "line" rules and the entropy
+	// check. If nil, the registered detectors that run by default are used.
+	Detectors []Detector
+
+	// Thresholds gives, by category, the number of warnings of the category
+	// a file must have for any of them to be reported
+	Thresholds map[string]int
 )
 
 const (
 	noNewlineWarning = "\\ No newline at end of file"
+
+	entropyCaption = "Possible key in high entropy string"
+	entropyRuleID  = "high-entropy-string"
 )
 
+// ScanOptions controls how a patch is read by Scan
+type ScanOptions struct {
+	// Report, if set, is called with the report for each file that has
+	// something worth reporting, as soon as its block of the patch has been
+	// read. Returning an error stops the scan.
+	Report func(Report) error
+}
+
 // SnoopPatch takes a raw github patch byte array and tests it against the
 // defined rulesets. Returns true if diff appears clean and false otherwise. In
 // the case of a potentially unclean diff, a report set will also be returned
-// detailing a set of warnings identified.
+// detailing a set of warnings identified. Reports are also returned for files
+// with suppressed warnings or that were skipped by the Ignore list, but these
+// alone don't make the diff unclean.
+//
+// The check is configured by rule.Sets and the package level settings.
 func SnoopPatch(patch []byte) (bool, []Report, error) {
+	return defaultScanner().SnoopPatch(patch)
+}
 
-	reader := bufio.NewReader(bytes.NewReader(patch))
+// Scan reads a patch from r and tests it against the defined rulesets in the
+// same way as SnoopPatch, but as a stream - only a line of the patch is held in
+// memory at a time, so it's suitable for very large patches. Reports are
+// passed to opts.Report as each file is finished with. Returns true if the
+// patch appears clean, or ctx's error if ctx is cancelled before the end of
+// the patch.
+//
+// The check is configured by rule.Sets and the package level settings.
+func Scan(ctx context.Context, r io.Reader, opts ScanOptions) (bool, error) {
+	return defaultScanner().Scan(ctx, r, opts)
+}
 
+// SnoopPatch is the package level SnoopPatch, configured by the Scanner's
+// Options
+func (s *Scanner) SnoopPatch(patch []byte) (bool, []Report, error) {
 	reports := []Report{}
+	ok, err := s.Scan(context.Background(), bytes.NewReader(patch), ScanOptions{
+		Report: func(r Report) error {
+			reports = append(reports, r)
+			return nil
+		},
+	})
+	if err != nil {
+		return false, nil, err
+	}
+
+	if len(reports) == 0 {
+		// All ok!
+		return true, nil, nil
+	}
+	return ok, reports, nil
+}
+
+// Scan is the package level Scan, configured by the Scanner's Options
+func (s *Scanner) Scan(ctx context.Context, r io.Reader, opts ScanOptions) (bool, error) {
+
+	reader := bufio.NewReader(r)
+
+	clean := true
 	report := Report{}
+	var (
+		file   *File
+		states []FileState
+		nb     *notebook // Set while reading a notebook
+	)
+
+	// Hands over the current report if there's anything in it
+	emit := func() error {
+		report.Warnings = append(report.Warnings, s.endFile(states)...)
+		states = nil
+		report = s.filterBaseline(s.threshold(report))
+
+		// Only once the warnings that won't be reported are gone, so that
+		// they can't crowd out new ones
+		report.Warnings = s.limit(report.Warnings)
+		if !report.notable() {
+			return nil
+		}
+		if len(report.Warnings) > 0 {
+			clean = false
+		}
+		if opts.Report != nil {
+			return opts.Report(report)
+		}
+		return nil
+	}
+
+	// Added lines are collected for each hunk and checked together when the
+	// hunk ends, or in parts for very large hunks
+	hunk := &Hunk{}
+	check := func() {
+		if len(hunk.Lines) > 0 {
+			w, suppressed := s.checkHunk(file, hunk, states)
+			report.Warnings = append(report.Warnings, w...)
+			report.Suppressed = append(report.Suppressed, suppressed...)
+		}
+		hunk = &Hunk{}
+	}
 
 	inHunk := false
 	linePosition := 0
@@ -75,22 +242,29 @@ func SnoopPatch(patch []byte) (bool, []Report, error) {
 	var tmp []byte
 
 	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		default:
+		}
+
 		line, isPrefix, err := reader.ReadLine()
 		if isPrefix {
 			// Long line. Temporarily store what we have and pick up the next
 			// chunk on the next pass.
-			if tmp == nil {
-				tmp = make([]byte, len(line))
-			}
 			tmp = append(tmp, line...)
 			continue
 		}
 		if err == io.EOF {
-			if len(report.Warnings) > 0 {
-				reports = append(reports, report)
+			check()
+			if err := emit(); err != nil {
+				return false, err
 			}
 			break
 		}
+		if err != nil {
+			return false, err
+		}
 		if tmp != nil {
 			// If we have temporarily stored long line data then dump it back out to the
 			// line and continue
@@ -105,15 +279,29 @@ func SnoopPatch(patch []byte) (bool, []Report, error) {
 
 			// If we already have previous warnings then we output the existing
 			// report and clear down.
-			if len(report.Warnings) > 0 {
-				reports = append(reports, report)
-				report = Report{}
+			check()
+			if err := emit(); err != nil {
+				return false, err
+			}
+			report = Report{}
+
+			report.Path, report.OldPath, err = getFilePath(line)
+			if err != nil {
+				return false, err
+			}
+			file = &File{Path: report.Path, OldPath: report.OldPath}
+			states = s.newFile(file)
+			nb = nil
+			if isNotebook(file.Path) {
+				nb = newNotebook()
 			}
 
-			report.Path, report.OldPath = getFilePath(line)
+			report.Ignored = s.opts.Ignore.Match(report.Path)
 
-			if ok, w := checkFile(report.Path); !ok {
-				report.Warnings = append(report.Warnings, w...)
+			if report.Ignored&ignore.File == 0 {
+				if ok, w := s.checkFile(report.Path); !ok {
+					report.Warnings = append(report.Warnings, w...)
+				}
 			}
 
 			continue
@@ -121,6 +309,7 @@ func SnoopPatch(patch []byte) (bool, []Report, error) {
 
 		// Check whether we're starting a new hunk
 		if bytes.HasPrefix(line, []byte("@@ ")) {
+			check()
 			matches := reOffset.FindAllSubmatch(line, -1)
 
 			// nb. If there are no matches then we just assume we're in a line
@@ -131,7 +320,7 @@ func SnoopPatch(patch []byte) (bool, []Report, error) {
 				linePosition, err = strconv.Atoi(string(matches[0][2]))
 				if err != nil {
 					// TODO handle better!
-					return false, nil, err
+					return false, err
 				}
 			}
 
@@ -139,9 +328,57 @@ func SnoopPatch(patch []byte) (bool, []Report, error) {
 			continue
 		}
 
-		if inHunk && string(line) != noNewlineWarning {
-			if ok, w := checkLineBytes(line, linePosition); !ok {
-				report.Warnings = append(report.Warnings, w...)
+		if !inHunk || string(line) == noNewlineWarning {
+			continue
+		}
+
+		// Removed lines aren't in the new file, so don't affect the line
+		// numbers and aren't checked
+		if bytes.HasPrefix(line, []byte("-")) {
+			continue
+		}
+
+		// Context lines, as in patches from git diff or git format-patch,
+		// aren't changed so aren't checked. They're still passed to the
+		// notebook parser, to follow the structure of the file.
+		context := bytes.HasPrefix(line, []byte(" "))
+
+		if report.Ignored&ignore.Line == 0 && (!context || nb != nil) {
+			// Copied, as line may be reused for the next line of the patch
+			text := append([]byte(nil), line...)
+			if len(text) > 0 && (text[0] == '+' || context) {
+				text = text[1:]
+			}
+			skip := context
+			if nb != nil {
+				var view *notebookLine
+				var binary bool
+				text, view, binary = nb.line(linePosition, text)
+				skip = skip || binary
+				if view != nil && !skip {
+					if hunk.views == nil {
+						hunk.views = make(map[int]*notebookLine)
+					}
+					hunk.views[linePosition] = view
+				}
+			}
+			if !skip {
+				hunk.Lines = append(hunk.Lines, Line{Number: linePosition, Text: text})
+			}
+			if len(hunk.Lines) >= maxHunkLines {
+				// The next part starts with the end of this one, so that
+				// hunk scope rules can match across the split
+				carried, views := overlap(hunk.Lines), hunk.views
+				check()
+				hunk.carried = carried
+				for _, l := range carried {
+					if view, ok := views[l.Number]; ok {
+						if hunk.views == nil {
+							hunk.views = make(map[int]*notebookLine)
+						}
+						hunk.views[l.Number] = view
+					}
+				}
 			}
 		}
 
@@ -152,49 +389,202 @@ func SnoopPatch(patch []byte) (bool, []Report, error) {
 
 	}
 
-	if len(reports) > 0 {
-		return false, reports, nil
+	return clean, nil
+}
+
+// filterBaseline fingerprints each of the report's warnings and removes any
+// that are in the Baseline
+func (s *Scanner) filterBaseline(r Report) Report {
+	warnings := []Warning{}
+	for _, w := range r.Warnings {
+		w.Fingerprint = baseline.Fingerprint(w.RuleID, r.Path, w.match)
+		if !s.opts.Baseline.Contains(w.Fingerprint) {
+			warnings = append(warnings, w)
+		}
 	}
+	r.Warnings = warnings
 
-	// All ok!
-	return true, nil, nil
+	for i, w := range r.Suppressed {
+		r.Suppressed[i].Fingerprint = baseline.Fingerprint(w.RuleID, r.Path, w.match)
+	}
+	return r
 }
 
-// checkLineBytes runs rules against each line in the patch for a file to see whether
-// they match potentially sensitive patterns
-// Returns true with a set of Warning structs if found, otherwise false
-func checkLineBytes(line []byte, position int) (bool, []Warning) {
+// notable reports whether there's anything in the report worth returning
+func (r Report) notable() bool {
+	return len(r.Warnings) > 0 || len(r.Suppressed) > 0 || r.Ignored != ignore.None
+}
 
-	warnings := []Warning{}
+// newFile returns the state of each FileDetector for a new file, in the same
+// order as the detectors. Other detectors have a nil state.
+func (s *Scanner) newFile(file *File) []FileState {
+	states := make([]FileState, len(s.detectors))
+	for i, d := range s.detectors {
+		if fd, ok := d.(FileDetector); ok {
+			states[i] = fd.NewFile(file)
+		}
+	}
+	return states
+}
 
-	// Check if it's a removed line - we don't care if it is
-	if bytes.HasPrefix(line, []byte(`-`)) {
-		return false, nil
+// endFile returns the warnings about a file as a whole from each FileDetector
+func (s *Scanner) endFile(states []FileState) []Warning {
+	var warnings []Warning
+	for _, state := range states {
+		if state == nil {
+			continue
+		}
+		for _, f := range state.End() {
+			if f.Line > 0 {
+				warnings = append(warnings, s.lineWarning(f, nil))
+				continue
+			}
+			w := s.lineWarning(f, nil)
+			w.Type, w.Line, w.EndLine = "file", -1, -1
+			w.StartColumn, w.EndColumn = 0, 0
+			warnings = append(warnings, w)
+		}
 	}
+	return warnings
+}
 
-	// Normal line rulesets
-	for _, rule := range rule.Sets["line"] {
-		if rule.Regex.Match(line) {
-			warnings = append(warnings, Warning{Type: "line", Description: rule.Caption, Line: position})
+// checkHunk runs the detectors against the added lines of a hunk to see
+// whether they match potentially sensitive patterns. FileDetectors are run
+// using their state for the file. Any warnings suppressed by an inline marker
+// are returned separately.
+func (s *Scanner) checkHunk(file *File, hunk *Hunk, states []FileState) ([]Warning, []Warning) {
+	texts := make(map[int][]byte, len(hunk.Lines))
+	for i, l := range hunk.Lines {
+		// Only the start of very long lines, e.g. minified files, is checked
+		if s.opts.MaxLineLength > 0 && len(l.Text) > s.opts.MaxLineLength {
+			hunk.Lines[i].Text = l.Text[:s.opts.MaxLineLength]
 		}
+		texts[l.Number] = hunk.Lines[i].Text
 	}
 
-	// Entropy check
-	if UseEntropy {
-		if ok, _ := entropy.Check(line); !ok {
-			warnings = append(warnings, Warning{Type: "line", Description: "Possible key in high entropy string", Line: position})
+	var findings []Finding
+	for i, d := range s.detectors {
+		if i < len(states) && states[i] != nil {
+			findings = append(findings, states[i].Detect(hunk)...)
+		} else {
+			findings = append(findings, d.Detect(file, hunk)...)
 		}
 	}
 
-	if len(warnings) > 0 {
-		return false, warnings
+	// In line order, then in the order of the detectors
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Line < findings[j].Line
+	})
+
+	var warnings, suppressed []Warning
+	for _, f := range findings {
+		w := s.lineWarning(f, texts[f.Line])
+		if hunk.views != nil {
+			notebookWarning(&w, hunk.views)
+		}
+
+		// A marker on any line of a match spanning several lines allows it
+		allowed := false
+		for n := w.Line; n <= w.EndLine && !allowed; n++ {
+			allowed = s.suppressed(texts[n], w.RuleID)
+		}
+		if allowed {
+			suppressed = append(suppressed, w)
+		} else {
+			warnings = append(warnings, w)
+		}
+	}
+	return warnings, suppressed
+}
+
+// suppressed reports whether a warning for the rule has been allowed by an
+// inline `diffcheck:allow` marker on the line. A bare marker allows everything
+// on the line, `diffcheck:allow rule=a,b` only allows the listed rules,
+// identified by their ID.
+func (s *Scanner) suppressed(line []byte, id string) bool {
+	if s.opts.DisableSuppression {
+		return false
+	}
+	m := reAllow.FindSubmatch(line)
+	if m == nil {
+		return false
+	}
+	return len(m[1]) == 0 || listed(string(m[1]), id)
+}
+
+// listed reports whether id is in the comma separated list
+func listed(list, id string) bool {
+	for _, item := range strings.Split(list, ",") {
+		if item == id {
+			return true
+		}
 	}
-	return false, nil
+	return false
+}
+
+// lineWarning builds the warning for a finding starting on the line of text
+func (s *Scanner) lineWarning(f Finding, text []byte) Warning {
+	match := f.Match
+	if match == nil && f.EndLine == 0 && f.Start <= f.End && f.End <= len(text) {
+		match = append([]byte(nil), text[f.Start:f.End]...)
+	}
+	if f.Severity == "" {
+		f.Severity = rule.Medium
+	}
+	if f.EndLine < f.Line {
+		f.EndLine = f.Line
+	}
+
+	w := Warning{
+		Type:        "line",
+		Description: f.Description,
+		Line:        f.Line,
+		EndLine:     f.EndLine,
+		RuleID:      f.RuleID,
+		Severity:    f.Severity,
+		Category:    f.Category,
+		Decoded:     f.Decoded,
+		Match:       s.redact(match),
+		match:       match,
+	}
+	if f.End > f.Start || f.EndLine > f.Line {
+		// Columns count from 1
+		w.StartColumn = f.Start + 1
+		w.EndColumn = f.End + 1
+	}
+	return w
+}
+
+// fileWarning builds the warning for a match of r on the file name
+func fileWarning(r rule.Rule, path string) Warning {
+	return Warning{
+		Type:        "file",
+		Description: r.Caption,
+		Line:        -1,
+		EndLine:     -1,
+		RuleID:      r.ID,
+		Severity:    r.Severity,
+		match:       []byte(path),
+	}
+}
+
+// redact hides all but the first few characters of a match, unless
+// ShowMatches is set. Short matches are hidden entirely.
+func (s *Scanner) redact(match []byte) string {
+	if s.opts.ShowMatches {
+		return string(match)
+	}
+	runes := []rune(string(match))
+	shown := 0
+	if len(runes) >= 12 {
+		shown = 4
+	}
+	return string(runes[:shown]) + strings.Repeat("*", len(runes)-shown)
 }
 
 // checkFile runs gitrob rules against the file name to see whether they match
 // Returns true with a set of Warning structs if found, otherwise false
-func checkFile(path string) (bool, []Warning) {
+func (s *Scanner) checkFile(path string) (bool, []Warning) {
 
 	// Prep the three possible bits that could be examined:
 	// - path (already have), just filename, and extension
@@ -206,7 +596,7 @@ func checkFile(path string) (bool, []Warning) {
 
 	warnings := []Warning{}
 
-	for _, rule := range rule.Sets["file"] {
+	for _, rule := range s.opts.Rules["file"] {
 		// Determine which bit of the filename we want to test
 		toTest := ""
 
@@ -222,11 +612,11 @@ func checkFile(path string) (bool, []Warning) {
 		switch rule.Type {
 		case "regex":
 			if rule.Regex.Match([]byte(toTest)) {
-				warnings = append(warnings, Warning{Type: "file", Description: rule.Caption, Line: -1})
+				warnings = append(warnings, fileWarning(rule, path))
 			}
 		case "match":
 			if rule.Pattern == toTest {
-				warnings = append(warnings, Warning{Type: "file", Description: rule.Caption, Line: -1})
+				warnings = append(warnings, fileWarning(rule, path))
 			}
 		}
 	}
@@ -236,13 +626,70 @@ func checkFile(path string) (bool, []Warning) {
 	return true, nil
 }
 
-// Returns the actual filename and previous filename (which may or may not
-// be different)
-func getFilePath(raw []byte) (string, string) {
-	words := bytes.Split(raw, []byte(" "))
+// getFilePath returns the new and old paths of a file from the "diff --git"
+// line that starts its block of the patch
+func getFilePath(raw []byte) (string, string, error) {
+	names := strings.TrimPrefix(string(raw), "diff --git ")
+
+	var old, new string
+	switch {
+	case strings.HasPrefix(names, `"`):
+		// git quotes paths with special characters
+		var err error
+		old, new, err = unquotePath(names)
+		if err != nil {
+			return "", "", fmt.Errorf("malformed diff header %q: %v", raw, err)
+		}
+		new = strings.TrimPrefix(new, " ")
+	case sameNames(names):
+		// Paths may contain spaces, so the names are split in the middle if
+		// they're the same path
+		old, new = names[:len(names)/2], names[len(names)/2+1:]
+	default:
+		i := strings.Index(names, " b/")
+		if i < 0 {
+			i = strings.Index(names, ` "b/`)
+		}
+		if i < 0 {
+			return "", "", fmt.Errorf("malformed diff header %q", raw)
+		}
+		old, new = names[:i], names[i+1:]
+	}
+
+	if strings.HasPrefix(new, `"`) {
+		var rest string
+		var err error
+		if new, rest, err = unquotePath(new); err != nil || rest != "" {
+			return "", "", fmt.Errorf("malformed diff header %q", raw)
+		}
+	}
+	if old == "" || new == "" {
+		return "", "", fmt.Errorf("malformed diff header %q", raw)
+	}
+	return strings.TrimPrefix(new, "b/"), strings.TrimPrefix(old, "a/"), nil
+}
 
-	new := string(bytes.TrimLeft(words[3], "b/"))
-	old := string(bytes.TrimLeft(words[2], "a/"))
+// sameNames reports whether the names on a "diff --git" line are the same
+// path, e.g. "a/my file.txt b/my file.txt"
+func sameNames(names string) bool {
+	mid := len(names) / 2
+	if len(names) < 5 || len(names)%2 == 0 || names[mid] != ' ' {
+		return false
+	}
+	return names[2:mid] == names[mid+3:]
+}
 
-	return new, old
+// unquotePath unquotes the C style quoted path at the start of s, returning
+// the path and the rest of s
+func unquotePath(s string) (string, string, error) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			path, err := strconv.Unquote(s[:i+1])
+			return path, s[i+1:], err
+		}
+	}
+	return "", "", fmt.Errorf("unterminated quoted path")
 }
@@ -0,0 +1,85 @@
+package diffcheck
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ONSdigital/git-diff-check/rule"
+)
+
+const dockerRuleID = "docker-config-auth"
+
+// dockerFiles are the names of Docker client config files
+var dockerFiles = map[string]bool{
+	"config.json":       true,
+	".dockercfg":        true,
+	"dockercfg":         true,
+	".dockerconfigjson": true,
+	"dockerconfig.json": true,
+}
+
+// dockerTokens are the keys of registry tokens in Docker client config files.
+// Passwords are reported by the config detector.
+var dockerTokens = map[string]bool{
+	"identitytoken": true,
+	"registrytoken": true,
+}
+
+// DockerDetector returns a Detector for Docker client config files, such as
+// `~/.docker/config.json`, that reports registry credentials - `auth` values
+// holding a base64 encoded username and password, and identity tokens
+func DockerDetector() Detector {
+	return dockerDetector{config: ConfigDetector(ConfigOptions{}).(configDetector)}
+}
+
+type dockerDetector struct {
+	config configDetector // For placeholder values
+}
+
+func init() {
+	Register(DockerDetector())
+}
+
+func (dockerDetector) Name() string { return "docker" }
+
+func (d dockerDetector) Detect(file *File, hunk *Hunk) []Finding {
+	if !dockerFiles[strings.ToLower(filepath.Base(file.Path))] {
+		return nil
+	}
+
+	var findings []Finding
+	for _, line := range hunk.Lines {
+		for _, p := range configPairs("json", line.Text) {
+			key := strings.ToLower(p.key)
+			switch {
+			case key == "auth" && dockerAuth(p.value):
+			case dockerTokens[key] && !d.config.placeholder(p.key, p.value):
+			default:
+				continue
+			}
+			findings = append(findings, Finding{
+				RuleID:      dockerRuleID,
+				Description: fmt.Sprintf("Docker registry credentials in %q", p.key),
+				Severity:    rule.High,
+				Line:        line.Number,
+				Start:       p.start,
+				End:         p.end,
+			})
+		}
+	}
+	return findings
+}
+
+// dockerAuth reports whether an `auth` value is a base64 encoded
+// `username:password`
+func dockerAuth(value string) bool {
+	b, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || !isText(b) {
+		return false
+	}
+	i := bytes.IndexByte(b, ':')
+	return i > 0 && i < len(b)-1
+}
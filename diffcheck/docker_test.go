@@ -0,0 +1,40 @@
+package diffcheck_test
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/git-diff-check/diffcheck"
+)
+
+func TestDockerDetector(t *testing.T) {
+	var tests = []struct {
+		Path  string
+		Line  string
+		Match string // Empty if nothing should be found
+	}{
+		{Path: ".docker/config.json", Line: `    "ghcr.io": {"auth": "ZGVwbG95OnMzY3IzdC1wYXNz"}`, Match: "ZGVwbG95OnMzY3IzdC1wYXNz"},
+		{Path: ".dockercfg", Line: `{"https://index.docker.io/v1/": {"auth": "ZGVwbG95OnMzY3IzdC1wYXNz", "email": "a@b.uk"}}`, Match: "ZGVwbG95OnMzY3IzdC1wYXNz"},
+		{Path: "config.json", Line: `      "identitytoken": "eyJhbGciOiJSUzI1NiJ9.e30.c2ln"`, Match: "eyJhbGciOiJSUzI1NiJ9.e30.c2ln"},
+		{Path: "config.json", Line: `      "auth": "bm90YWNyZWRlbnRpYWw="`},
+		{Path: "config.json", Line: `      "auth": "oauth2"`},
+		{Path: "config.json", Line: `      "identitytoken": "${TOKEN}"`},
+		{Path: "config.json", Line: `  "credsStore": "desktop"`},
+		{Path: "settings.json", Line: `    "ghcr.io": {"auth": "ZGVwbG95OnMzY3IzdC1wYXNz"}`},
+	}
+
+	for _, tc := range tests {
+		got := detect(t, diffcheck.DockerDetector(), tc.Path, []string{tc.Line})
+		if tc.Match == "" {
+			if len(got) > 0 {
+				t.Errorf("%s in %s: Expected no warnings, got %+v", tc.Line, tc.Path, got)
+			}
+			continue
+		}
+		if len(got) != 1 {
+			t.Errorf("%s in %s: Expected 1 warning, got %+v", tc.Line, tc.Path, got)
+			continue
+		}
+		shouldEqual("rule ID", got[0].RuleID, "docker-config-auth", t)
+		shouldEqual("match", got[0].Match, tc.Match, t)
+	}
+}
@@ -0,0 +1,154 @@
+package diffcheck
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/ONSdigital/git-diff-check/rule"
+)
+
+const kubernetesRuleID = "kubernetes-secret"
+
+// reYAMLTopKey matches a key at the top level of a YAML document
+var reYAMLTopKey = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_-]*)\s*:`)
+
+// KubernetesDetector returns a Detector for Kubernetes manifests that reports
+// each field in the data and stringData of a Secret. SealedSecrets, and values
+// encrypted by SOPS, aren't reported.
+func KubernetesDetector() Detector {
+	return kubernetesDetector{config: ConfigDetector(ConfigOptions{}).(configDetector)}
+}
+
+type kubernetesDetector struct {
+	config configDetector // For placeholder values
+}
+
+func init() {
+	Register(KubernetesDetector())
+}
+
+func (kubernetesDetector) Name() string { return "kubernetes" }
+
+func (d kubernetesDetector) Detect(file *File, hunk *Hunk) []Finding {
+	state := d.NewFile(file)
+	return append(state.Detect(hunk), state.End()...)
+}
+
+func (d kubernetesDetector) NewFile(file *File) FileState {
+	if configFormat(file.Path) != "yaml" {
+		return noFileState{}
+	}
+	return &kubernetesFile{config: d.config, doc: newYAMLDocument()}
+}
+
+// kubernetesFile is the state of the Kubernetes detector for a single file
+type kubernetesFile struct {
+	config configDetector
+	doc    *yamlDocument
+
+	// Secret fields found before the kind of their document
+	pending []Finding
+}
+
+func (f *kubernetesFile) Detect(hunk *Hunk) []Finding {
+	var findings []Finding
+	for _, line := range hunk.Lines {
+		count := f.doc.count
+		f.doc.line(line.Number, line.Text)
+		if f.doc.count != count {
+			f.pending = nil
+		}
+
+		switch f.doc.kind {
+		case "Secret":
+			findings = append(findings, f.pending...)
+			f.pending = nil
+		case "":
+		default:
+			continue
+		}
+		if !f.doc.secretData() || !f.doc.indented(line.Text) {
+			continue
+		}
+
+		for _, p := range configPairs("yaml", line.Text) {
+			if f.config.placeholder(p.key, p.value) {
+				continue
+			}
+			finding := Finding{
+				RuleID:      kubernetesRuleID,
+				Description: fmt.Sprintf("Kubernetes Secret %s field %q", f.doc.section, p.key),
+				Severity:    rule.High,
+				Line:        line.Number,
+				Start:       p.start,
+				End:         p.end,
+				Match:       []byte(p.value),
+			}
+			if f.doc.kind == "" {
+				f.pending = append(f.pending, finding)
+			} else {
+				findings = append(findings, finding)
+			}
+		}
+	}
+	return findings
+}
+
+// End drops the fields of documents whose kind was never seen
+func (f *kubernetesFile) End() []Finding { return nil }
+
+// yamlDocument follows the top level of the YAML documents in a file as its
+// added lines are read. The top level key a line is under is only known while
+// the lines are consecutive.
+type yamlDocument struct {
+	next    int    // Number of the next line
+	count   int    // Number of documents started
+	kind    string // Kind of the current document, if known
+	section string // Top level key of the current line, if known
+}
+
+func newYAMLDocument() *yamlDocument {
+	return &yamlDocument{next: 1}
+}
+
+// line reads the next added line
+func (d *yamlDocument) line(number int, text []byte) {
+	if number != d.next {
+		d.section = ""
+	}
+	d.next = number + 1
+
+	if bytes.HasPrefix(text, []byte("---")) {
+		d.count++
+		d.kind, d.section = "", ""
+		return
+	}
+	loc := reYAMLTopKey.FindSubmatchIndex(text)
+	if loc == nil {
+		return
+	}
+	d.section = string(text[loc[2]:loc[3]])
+	if d.section == "kind" {
+		if start, end, ok := configValue("yaml", text, loc[1]); ok {
+			d.kind = string(bytes.Trim(text[start:end], `"'`))
+		}
+	}
+}
+
+// indented reports whether a line is inside a top level key
+func (d *yamlDocument) indented(text []byte) bool {
+	return len(text) > 0 && (text[0] == ' ' || text[0] == '\t')
+}
+
+// secretData reports whether the current line is in the data of a Secret, or
+// of a document that could be one
+func (d *yamlDocument) secretData() bool {
+	return (d.kind == "Secret" || d.kind == "") && (d.section == "data" || d.section == "stringData")
+}
+
+// sealed reports whether the current document is a SealedSecret, whose
+// values are encrypted
+func (d *yamlDocument) sealed() bool {
+	return d.kind == "SealedSecret"
+}
@@ -0,0 +1,186 @@
+package diffcheck_test
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/git-diff-check/diffcheck"
+	"github.com/ONSdigital/git-diff-check/rule"
+)
+
+// detect returns the warnings found by a single detector, without any rules,
+// in a patch adding a file at path with the given lines
+func detect(t *testing.T, d diffcheck.Detector, path string, lines []string) []diffcheck.Warning {
+	s := diffcheck.New(diffcheck.Options{Rules: map[string][]rule.Rule{}, Detectors: []diffcheck.Detector{d}, ShowMatches: true})
+	_, reports, err := s.SnoopPatch(dataPatch(path, lines))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	var got []diffcheck.Warning
+	for _, r := range reports {
+		got = append(got, r.Warnings...)
+	}
+	return got
+}
+
+func TestKubernetesDetector(t *testing.T) {
+	var tests = []struct {
+		Name     string
+		Path     string
+		Lines    []string
+		Expected []diffcheck.Warning
+	}{
+		{
+			Name: "secret",
+			Path: "deploy/secret.yaml",
+			Lines: []string{
+				"apiVersion: v1",
+				"kind: Secret",
+				"metadata:",
+				"  name: db",
+				"type: Opaque",
+				"data:",
+				"  username: YWRtaW4=",
+				"  password: aHVudGVyMg== # base64",
+				"stringData:",
+				"  api-key: \"k3y-9f8a7\"",
+			},
+			Expected: []diffcheck.Warning{
+				{RuleID: "kubernetes-secret", Line: 7, StartColumn: 13, EndColumn: 21, Match: "YWRtaW4="},
+				{RuleID: "kubernetes-secret", Line: 8, StartColumn: 13, EndColumn: 25, Match: "aHVudGVyMg=="},
+				{RuleID: "kubernetes-secret", Line: 10, StartColumn: 13, EndColumn: 22, Match: "k3y-9f8a7"},
+			},
+		},
+		{
+			Name: "kind after data",
+			Path: "secret.yml",
+			Lines: []string{
+				"data:",
+				"  token: dG9rZW4tdmFsdWU=",
+				"kind: Secret",
+			},
+			Expected: []diffcheck.Warning{
+				{RuleID: "kubernetes-secret", Line: 2, StartColumn: 10, EndColumn: 26, Match: "dG9rZW4tdmFsdWU="},
+			},
+		},
+		{
+			Name: "several documents",
+			Path: "manifests.yaml",
+			Lines: []string{
+				"kind: ConfigMap",
+				"data:",
+				"  log_level: debug",
+				"---",
+				"kind: Secret",
+				"data:",
+				"  token: dG9rZW4tdmFsdWU=",
+			},
+			Expected: []diffcheck.Warning{
+				{RuleID: "kubernetes-secret", Line: 7, StartColumn: 10, EndColumn: 26, Match: "dG9rZW4tdmFsdWU="},
+			},
+		},
+		{
+			Name: "sealed secret",
+			Path: "sealed.yaml",
+			Lines: []string{
+				"apiVersion: bitnami.com/v1alpha1",
+				"kind: SealedSecret",
+				"spec:",
+				"  encryptedData:",
+				"    password: AgBy3i4OJSWK+PiTySYZZA9rO43cGDEq",
+			},
+		},
+		{
+			Name: "SOPS encrypted secret",
+			Path: "secret.enc.yaml",
+			Lines: []string{
+				"kind: Secret",
+				"data:",
+				"  password: ENC[AES256_GCM,data:Tr7o1x==,iv:1x==,tag:2x==,type:str]",
+				"sops:",
+				"  mac: ENC[AES256_GCM,data:abc=,iv:def=,tag:ghi=,type:str]",
+			},
+		},
+		{
+			Name: "templated secret",
+			Path: "chart/templates/secret.yaml",
+			Lines: []string{
+				"kind: Secret",
+				"data:",
+				"  password: {{ .Values.password | b64enc }}",
+			},
+		},
+		{
+			Name: "config map",
+			Path: "configmap.yaml",
+			Lines: []string{
+				"kind: ConfigMap",
+				"data:",
+				"  log_level: debug",
+			},
+		},
+		{
+			Name:  "not YAML",
+			Path:  "secret.txt",
+			Lines: []string{"kind: Secret", "data:", "  password: aHVudGVyMg=="},
+		},
+	}
+
+	for _, tc := range tests {
+		got := detect(t, diffcheck.KubernetesDetector(), tc.Path, tc.Lines)
+		if len(got) != len(tc.Expected) {
+			t.Errorf("%s: Expected %d warnings, got %+v", tc.Name, len(tc.Expected), got)
+			continue
+		}
+		for i, e := range tc.Expected {
+			w := got[i]
+			shouldEqual("rule ID", w.RuleID, e.RuleID, t)
+			shouldEqualInt("line", w.Line, e.Line, t)
+			shouldEqualInt("start column", w.StartColumn, e.StartColumn, t)
+			shouldEqualInt("end column", w.EndColumn, e.EndColumn, t)
+			shouldEqual("match", w.Match, e.Match, t)
+		}
+	}
+}
+
+func TestConfigDetectorSkipsSecrets(t *testing.T) {
+	var tests = []struct {
+		Name     string
+		Lines    []string
+		Expected int
+	}{
+		{
+			Name:  "sealed secret",
+			Lines: []string{"kind: SealedSecret", "spec:", "  encryptedData:", "    password: AgBy3i4OJSWK+PiTySYZZA9rO43cGDEq"},
+		},
+		{
+			Name:  "secret data reported by the kubernetes detector",
+			Lines: []string{"kind: Secret", "stringData:", "  password: hunter2"},
+		},
+		{
+			Name:     "other documents",
+			Lines:    []string{"kind: SealedSecret", "---", "kind: Deployment", "spec:", "  password: hunter2"},
+			Expected: 1,
+		},
+		{
+			Name:  "secret data before the kind, as kubectl sorts keys",
+			Lines: []string{"apiVersion: v1", "data:", "  password: aHVudGVyMg==", "kind: Secret", "metadata:", "  name: db"},
+		},
+		{
+			Name:     "data before another kind",
+			Lines:    []string{"apiVersion: v1", "data:", "  password: hunter2", "kind: ConfigMap", "metadata:", "  name: db"},
+			Expected: 1,
+		},
+		{
+			Name:     "data in a document without a kind",
+			Lines:    []string{"data:", "  password: hunter2", "---", "data:", "  password: hunter2"},
+			Expected: 2,
+		},
+	}
+
+	for _, tc := range tests {
+		got := detect(t, diffcheck.ConfigDetector(diffcheck.ConfigOptions{}), "deploy.yaml", tc.Lines)
+		if len(got) != tc.Expected {
+			t.Errorf("%s: Expected %d warnings, got %+v", tc.Name, tc.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package diffcheck
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// NotebookCell identifies where in a Jupyter notebook a warning was found
+type NotebookCell struct {
+	// Position of the cell in the notebook, counting from 1
+	Number int
+
+	// Type of the cell - "code", "markdown" or "raw"
+	Type string
+
+	// Part of the cell - "source" or "output"
+	Part string
+}
+
+func (c *NotebookCell) String() string {
+	if c.Type == "" {
+		return fmt.Sprintf("cell %d %s", c.Number, c.Part)
+	}
+	return fmt.Sprintf("cell %d %s %s", c.Number, c.Type, c.Part)
+}
+
+// isNotebook reports whether the file at path is a Jupyter notebook
+func isNotebook(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".ipynb")
+}
+
+// notebook follows the structure of a notebook's JSON as its added lines are
+// read, so that the strings in it can be checked as the text they hold
+// rather than escaped JSON. Notebooks are saved with each string in a cell's
+// source and outputs on its own line, so each line is decoded on its own.
+//
+// Which cell a line is in is only known while the lines are consecutive from
+// the start of the file, i.e. for new notebooks. Strings are still decoded
+// for changes to existing notebooks, but without the cell.
+type notebook struct {
+	next  int  // Number of the next line if the structure is still known
+	known bool // Whether the structure is known
+
+	stack []string      // Key of each open object and array
+	cells int           // Number of cells started
+	cell  *notebookCell // Current cell
+	depth int           // Length of stack inside the current cell
+}
+
+// notebookCell is the cell being read. Its type may not be known until after
+// some of its lines have been read.
+type notebookCell struct {
+	number int
+	typ    string
+}
+
+// notebookLine describes a decoded line of a notebook
+type notebookLine struct {
+	cell *notebookCell // nil if not known
+	part string
+
+	// Offset in the raw line of each byte of the decoded text, and of the
+	// end of the string
+	offsets []int
+}
+
+func newNotebook() *notebook {
+	return &notebook{next: 1, known: true}
+}
+
+// line returns the text to check for a line of the notebook, and how it maps
+// back on to the line. The line is returned unchanged, with a nil
+// notebookLine, if it doesn't hold a string. skip is set for lines that
+// shouldn't be checked, such as base64 encoded images.
+func (nb *notebook) line(number int, raw []byte) (text []byte, view *notebookLine, skip bool) {
+	if number != nb.next {
+		nb.known = false
+	}
+	nb.next = number + 1
+
+	key, value, rest := splitJSONLine(raw)
+	part, binary := nb.part(key)
+
+	if key == "cell_type" && nb.cell != nil && len(nb.stack) == nb.depth && value >= 0 {
+		if s, _, err := decodeJSONString(raw, value); err == nil {
+			nb.cell.typ = string(s)
+		}
+	}
+	nb.brackets(key, raw[rest:])
+
+	if binary {
+		return nil, nil, true
+	}
+	if value < 0 {
+		return raw, nil, false
+	}
+	decoded, offsets, err := decodeJSONString(raw, value)
+	if err != nil {
+		return raw, nil, false
+	}
+	if n := len(decoded); n > 0 && decoded[n-1] == '\n' {
+		// Each line of source or output text ends with its newline
+		decoded, offsets = decoded[:n-1], offsets[:n]
+	}
+
+	view = &notebookLine{offsets: offsets}
+	if nb.known && part != "" {
+		view.cell, view.part = nb.cell, part
+	}
+	return decoded, view, false
+}
+
+// part returns the part of the current cell a line with the given key is in,
+// and whether it's binary output data
+func (nb *notebook) part(key string) (string, bool) {
+	keys := append(nb.stack, key)
+	if !nb.known {
+		// Only the key on the line itself is known
+		keys = []string{key}
+	}
+
+	part, binary := "", false
+	for i, k := range keys {
+		if nb.known && nb.cell != nil && i < nb.depth {
+			continue
+		}
+		switch {
+		case k == "source":
+			part = "source"
+		case k == "outputs":
+			part = "output"
+		case strings.HasPrefix(k, "image/"), k == "application/pdf":
+			binary = true
+		}
+	}
+	return part, binary
+}
+
+// brackets follows the objects and arrays opened and closed in the rest of a
+// line, outside of strings. The first opened takes key as its key.
+func (nb *notebook) brackets(key string, rest []byte) {
+	inString := false
+	for i := 0; i < len(rest); i++ {
+		c := rest[i]
+		switch {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{' || c == '[':
+			if c == '{' && nb.known && len(nb.stack) == 2 && nb.stack[1] == "cells" {
+				nb.cells++
+				nb.cell = &notebookCell{number: nb.cells}
+				nb.depth = len(nb.stack) + 1
+			}
+			nb.stack = append(nb.stack, key)
+			key = ""
+		case c == '}' || c == ']':
+			if len(nb.stack) > 0 {
+				nb.stack = nb.stack[:len(nb.stack)-1]
+			}
+			if len(nb.stack) < nb.depth {
+				nb.cell, nb.depth = nil, 0
+			}
+		}
+	}
+}
+
+// splitJSONLine splits a line of pretty printed JSON into its key, if it has
+// one, the offset of its value if that's a string (or -1), and the offset of
+// the rest of the line after the key and any string value
+func splitJSONLine(raw []byte) (key string, value, rest int) {
+	i := len(raw) - len(bytes.TrimLeft(raw, " \t"))
+	if i == len(raw) || raw[i] != '"' {
+		return "", -1, i
+	}
+
+	end := stringEnd(raw, i)
+	if end < 0 {
+		return "", -1, i
+	}
+	j := end + len(raw[end:]) - len(bytes.TrimLeft(raw[end:], " \t"))
+	if j == len(raw) || raw[j] != ':' {
+		// A string in an array
+		return "", i, end
+	}
+
+	if k, err := strconv.Unquote(string(raw[i:end])); err == nil {
+		key = k
+	}
+	j++
+	j += len(raw[j:]) - len(bytes.TrimLeft(raw[j:], " \t"))
+	if j < len(raw) && raw[j] == '"' {
+		if end := stringEnd(raw, j); end >= 0 {
+			return key, j, end
+		}
+	}
+	return key, -1, j
+}
+
+// stringEnd returns the offset after the JSON string starting at start, or
+// -1 if it isn't closed on the line
+func stringEnd(raw []byte, start int) int {
+	for i := start + 1; i < len(raw); i++ {
+		switch raw[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// decodeJSONString decodes the JSON string starting at start, returning the
+// offset in raw of each decoded byte and of the closing quote
+func decodeJSONString(raw []byte, start int) ([]byte, []int, error) {
+	var (
+		out     []byte
+		offsets []int
+	)
+	add := func(b []byte, offset int) {
+		out = append(out, b...)
+		for range b {
+			offsets = append(offsets, offset)
+		}
+	}
+
+	for i := start + 1; i < len(raw); {
+		c := raw[i]
+		switch {
+		case c == '"':
+			return out, append(offsets, i), nil
+		case c != '\\':
+			add(raw[i:i+1], i)
+			i++
+		case i+1 >= len(raw):
+			return nil, nil, fmt.Errorf("unterminated escape")
+		case raw[i+1] == 'u':
+			r, n, err := decodeUnicodeEscape(raw[i:])
+			if err != nil {
+				return nil, nil, err
+			}
+			b := make([]byte, utf8.UTFMax)
+			add(b[:utf8.EncodeRune(b, r)], i)
+			i += n
+		default:
+			e, ok := jsonEscapes[raw[i+1]]
+			if !ok {
+				return nil, nil, fmt.Errorf("invalid escape %q", raw[i:i+2])
+			}
+			add([]byte{e}, i)
+			i += 2
+		}
+	}
+	return nil, nil, fmt.Errorf("unterminated string")
+}
+
+// jsonEscapes maps the character after a `\` to the character it stands for
+var jsonEscapes = map[byte]byte{
+	'"': '"', '\\': '\\', '/': '/', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t',
+}
+
+// decodeUnicodeEscape decodes a `\uXXXX` escape, or a pair of them for a
+// surrogate pair, returning the rune and the number of bytes used
+func decodeUnicodeEscape(b []byte) (rune, int, error) {
+	hex := func(b []byte) (rune, bool) {
+		if len(b) < 6 || b[0] != '\\' || b[1] != 'u' {
+			return 0, false
+		}
+		n, err := strconv.ParseUint(string(b[2:6]), 16, 16)
+		return rune(n), err == nil
+	}
+
+	r, ok := hex(b)
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid escape %q", b)
+	}
+	if utf16.IsSurrogate(r) {
+		if r2, ok := hex(b[6:]); ok {
+			if d := utf16.DecodeRune(r, r2); d != utf8.RuneError {
+				return d, 12, nil
+			}
+		}
+		return utf8.RuneError, 6, nil
+	}
+	return r, 6, nil
+}
+
+// notebookWarning sets the cell of a warning found in decoded notebook lines,
+// and maps its columns back on to the raw lines
+func notebookWarning(w *Warning, views map[int]*notebookLine) {
+	start, end := views[w.Line], views[w.EndLine]
+	if start != nil && start.cell != nil {
+		w.Cell = &NotebookCell{Number: start.cell.number, Type: start.cell.typ, Part: start.part}
+	}
+	if w.StartColumn == 0 {
+		return
+	}
+	if start != nil && w.StartColumn <= len(start.offsets) {
+		w.StartColumn = start.offsets[w.StartColumn-1] + 1
+	}
+	if end != nil && w.EndColumn <= len(end.offsets) {
+		w.EndColumn = end.offsets[w.EndColumn-1] + 1
+	}
+}
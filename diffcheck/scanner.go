@@ -0,0 +1,126 @@
+package diffcheck
+
+import (
+	"github.com/ONSdigital/git-diff-check/baseline"
+	"github.com/ONSdigital/git-diff-check/ignore"
+	"github.com/ONSdigital/git-diff-check/rule"
+)
+
+// Options configures a Scanner
+type Options struct {
+	// Rules to check against, keyed by ruleset - "file" for rules matched
+	// against file names and "line" for rules matched against added lines.
+	// If nil, rule.Sets is used.
+	Rules map[string][]rule.Rule
+
+	// Entropy enables experimental string entropy testing
+	Entropy bool
+
+	// Ignore is a list of patterns for files that shouldn't be scanned, or
+	// should only be scanned with some of the rulesets
+	Ignore *ignore.List
+
+	// Baseline is a set of known findings which won't be reported
+	Baseline *baseline.Baseline
+
+	// DisableSuppression ignores inline `diffcheck:allow` markers, e.g. where
+	// the people whose changes are being checked shouldn't be able to
+	// suppress warnings
+	DisableSuppression bool
+
+	// ShowMatches includes the full matched text in each Warning rather
+	// than a redacted version
+	ShowMatches bool
+
+	// MaxLineLength, if not 0, limits how much of each line is checked, so
+	// that very long lines such as minified files don't slow the check down
+	MaxLineLength int
+
+	// MaxWarnings, if not 0, limits the number of warnings reported for each
+	// file
+	MaxWarnings int
+
+	// Detectors to run in addition to the "line" rules and the entropy
+	// check. If nil, the registered detectors that run by default are used -
+	// see Register.
+	Detectors []Detector
+
+	// Thresholds gives, by category, the number of warnings of the category
+	// a file must have for any of them to be reported, e.g. so that a single
+	// postcode in documentation doesn't fail a check. Warnings in categories
+	// without a threshold are always reported.
+	Thresholds map[string]int
+}
+
+// Scanner checks patches for potentially sensitive information. A Scanner is
+// safe to use from many goroutines at once, as long as the rules, ignore list
+// and baseline it was made with aren't changed while it's in use.
+type Scanner struct {
+	opts      Options
+	detectors []Detector
+}
+
+// New returns a Scanner configured by opts
+func New(opts Options) *Scanner {
+	if opts.Rules == nil {
+		opts.Rules = rule.Sets
+	}
+	if opts.Detectors == nil {
+		opts.Detectors = DefaultDetectors()
+	}
+
+	detectors := []Detector{RuleDetector(opts.Rules["line"])}
+	if opts.Entropy {
+		detectors = append(detectors, EntropyDetector())
+	}
+	for _, d := range opts.Detectors {
+		if u, ok := d.(ruleUser); ok {
+			d = u.withRules(opts.Rules)
+		}
+		detectors = append(detectors, d)
+	}
+
+	return &Scanner{opts: opts, detectors: detectors}
+}
+
+// defaultScanner returns a Scanner configured by rule.Sets and the package
+// level settings, as they are at the time of the call
+func defaultScanner() *Scanner {
+	return New(Options{
+		Rules:       rule.Sets,
+		Entropy:     UseEntropy,
+		Ignore:      Ignore,
+		Baseline:    Baseline,
+		ShowMatches: ShowMatches,
+		Detectors:   Detectors,
+		Thresholds:  Thresholds,
+	})
+}
+
+// threshold drops the warnings in categories that the file has fewer of than
+// the category's threshold
+func (s *Scanner) threshold(r Report) Report {
+	if len(s.opts.Thresholds) == 0 {
+		return r
+	}
+	counts := map[string]int{}
+	for _, w := range r.Warnings {
+		counts[w.Category]++
+	}
+	warnings := []Warning{}
+	for _, w := range r.Warnings {
+		if w.Category == "" || counts[w.Category] >= s.opts.Thresholds[w.Category] {
+			warnings = append(warnings, w)
+		}
+	}
+	r.Warnings = warnings
+	return r
+}
+
+// limit drops any warnings over the MaxWarnings limit
+func (s *Scanner) limit(warnings []Warning) []Warning {
+	if s.opts.MaxWarnings > 0 && len(warnings) > s.opts.MaxWarnings {
+		return warnings[:s.opts.MaxWarnings]
+	}
+	return warnings
+}
@@ -0,0 +1,116 @@
+package diffcheck
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ONSdigital/git-diff-check/rule"
+)
+
+const terraformRuleID = "terraform-state-secret"
+
+var (
+	// reJSONObject matches a line opening an object with a key
+	reJSONObject = regexp.MustCompile(`^\s*"((?:[^"\\]|\\.)*)"\s*:\s*\{\s*$`)
+
+	// reTerraformSection matches the top level keys of a state file, which
+	// Terraform indents by two spaces
+	reTerraformSection = regexp.MustCompile(`^  "([a-z_]+)"\s*:`)
+
+	reTerraformSensitive = regexp.MustCompile(`^\s*"sensitive"\s*:\s*true\b`)
+)
+
+// TerraformDetector returns a Detector for Terraform state files that reports
+// resource attributes named like secrets, e.g. `password` or
+// `private_key_pem`, and the values of outputs marked as sensitive
+func TerraformDetector() Detector {
+	return terraformDetector{config: ConfigDetector(ConfigOptions{}).(configDetector)}
+}
+
+type terraformDetector struct {
+	config configDetector // For sensitive keys and placeholder values
+}
+
+func init() {
+	Register(TerraformDetector())
+}
+
+func (terraformDetector) Name() string { return "terraform" }
+
+func (d terraformDetector) Detect(file *File, hunk *Hunk) []Finding {
+	state := d.NewFile(file)
+	return append(state.Detect(hunk), state.End()...)
+}
+
+func (d terraformDetector) NewFile(file *File) FileState {
+	name := strings.ToLower(filepath.Base(file.Path))
+	if !strings.HasSuffix(name, ".tfstate") && !strings.HasSuffix(name, ".tfstate.backup") {
+		return noFileState{}
+	}
+	return &terraformFile{config: d.config}
+}
+
+// terraformFile is the state of the Terraform detector for a single file.
+// State files are always written by Terraform as indented JSON, with each
+// attribute on its own line.
+type terraformFile struct {
+	config configDetector
+
+	section string   // Top level key, e.g. "outputs" or "resources"
+	object  string   // Key of the last object opened, e.g. an output name
+	value   *Finding // Value of the current output, until it's known whether it's sensitive
+}
+
+func (f *terraformFile) Detect(hunk *Hunk) []Finding {
+	var findings []Finding
+	for _, line := range hunk.Lines {
+		if m := reTerraformSection.FindSubmatch(line.Text); m != nil {
+			f.section = string(m[1])
+		}
+		if m := reJSONObject.FindSubmatch(line.Text); m != nil {
+			f.object, f.value = string(m[1]), nil
+			continue
+		}
+		if bytes.HasPrefix(bytes.TrimSpace(line.Text), []byte("}")) {
+			f.object, f.value = "", nil
+			continue
+		}
+		if reTerraformSensitive.Match(line.Text) && f.value != nil {
+			findings = append(findings, *f.value)
+			f.value = nil
+			continue
+		}
+
+		for _, p := range configPairs("json", line.Text) {
+			if f.config.placeholder(p.key, p.value) {
+				continue
+			}
+			finding := Finding{
+				RuleID:      terraformRuleID,
+				Description: fmt.Sprintf("Terraform state attribute %q", p.key),
+				Severity:    rule.High,
+				Line:        line.Number,
+				Start:       p.start,
+				End:         p.end,
+				Match:       []byte(p.value),
+			}
+			switch {
+			case f.section == "outputs" && p.key == "value" && f.object != "":
+				finding.Description = fmt.Sprintf("Terraform state output %q", f.object)
+				if f.config.sensitive(f.object) {
+					findings = append(findings, finding)
+				} else {
+					f.value = &finding
+				}
+			case f.config.sensitive(p.key):
+				findings = append(findings, finding)
+			}
+		}
+	}
+	return findings
+}
+
+func (f *terraformFile) End() []Finding { return nil }
@@ -27,7 +27,7 @@ func CalculateShannon(data []byte) float64 {
 	entropy := 0.0
 	pX := 0.0
 	for x := 0; x < 256; x++ {
-		pX = float64(bytes.Count(data, []byte(string(x)))) / float64(len(data))
+		pX = float64(bytes.Count(data, []byte(string(rune(x))))) / float64(len(data))
 		if pX > 0 {
 			entropy += -pX * math.Log2(pX)
 		}
@@ -38,6 +38,12 @@ func CalculateShannon(data []byte) float64 {
 // Check searches through a given block of data to attempt to identify high
 // entropy blocks. Returns true and number of matching strings if found
 func Check(b []byte) (bool, int) {
+	found := Find(b)
+	return len(found) == 0, len(found)
+}
+
+// Find returns each of the high entropy strings in a given block of data
+func Find(b []byte) [][]byte {
 	found := [][]byte{}
 
 	// Offset where we started reading the data - indexes from
@@ -74,7 +80,7 @@ func Check(b []byte) (bool, int) {
 		}
 	}
 
-	return len(found) == 0, len(found)
+	return found
 }
 
 func isBase64Byte(b byte) bool {
@@ -0,0 +1,252 @@
+// Package gitutil runs the git commands needed to get patches, and details of
+// the commits they came from, out of a repository.
+package gitutil
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit describes a single commit
+type Commit struct {
+	SHA     string
+	Author  string // Name and email, e.g. "A Person <a.person@example.com>"
+	Date    time.Time
+	Subject string
+}
+
+// Short returns the abbreviated commit SHA
+func (c Commit) Short() string {
+	if len(c.SHA) > 7 {
+		return c.SHA[:7]
+	}
+	return c.SHA
+}
+
+// Run runs a git command in dir and returns its output. If the command fails
+// the returned error includes whatever git wrote to stderr.
+func Run(dir string, args ...string) ([]byte, error) {
+	return RunContext(context.Background(), dir, args...)
+}
+
+// RunContext is Run, but git is killed if ctx is done before it finishes
+func RunContext(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v (%s)", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// Stream runs a git command in dir, passing its output to fn to be read as it's
+// written rather than held in memory. If fn returns an error, git is stopped
+// and the error is returned.
+func Stream(dir string, args []string, fn func(r io.Reader) error) error {
+	return StreamContext(context.Background(), dir, args, fn)
+}
+
+// StreamContext is Stream, but git is killed if ctx is done before it
+// finishes
+func StreamContext(ctx context.Context, dir string, args []string, fn func(r io.Reader) error) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	if err := fn(stdout); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+
+	// Drain anything fn didn't read so that git can finish
+	io.Copy(ioutil.Discard, stdout)
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("git %s: %v (%s)", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// RevList returns the SHAs of the commits selected by the rev-list arguments,
+// e.g. "main..feature", oldest first
+func RevList(dir string, args ...string) ([]string, error) {
+	return RevListContext(context.Background(), dir, args...)
+}
+
+// RevListContext is RevList, but git is killed if ctx is done before it
+// finishes
+func RevListContext(ctx context.Context, dir string, args ...string) ([]string, error) {
+	out, err := RunContext(ctx, dir, append([]string{"rev-list", "--reverse"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// commitFormat separates the fields of a commit with NUL bytes
+const commitFormat = "%H%x00%an <%ae>%x00%aI%x00%s"
+
+// Show returns the details of the commit identified by rev
+func Show(dir, rev string) (Commit, error) {
+	return ShowContext(context.Background(), dir, rev)
+}
+
+// ShowContext is Show, but git is killed if ctx is done before it finishes
+func ShowContext(ctx context.Context, dir, rev string) (Commit, error) {
+	out, err := RunContext(ctx, dir, "show", "-s", "--format="+commitFormat, rev)
+	if err != nil {
+		return Commit{}, err
+	}
+	return parseCommit(strings.TrimRight(string(out), "\n"))
+}
+
+func parseCommit(s string) (Commit, error) {
+	fields := strings.SplitN(s, "\x00", 4)
+	if len(fields) != 4 {
+		return Commit{}, fmt.Errorf("unexpected commit format %q", s)
+	}
+	date, err := time.Parse(time.RFC3339, fields[2])
+	if err != nil {
+		return Commit{}, err
+	}
+	return Commit{SHA: fields[0], Author: fields[1], Date: date, Subject: fields[3]}, nil
+}
+
+// Patch returns the changes introduced by the commit identified by rev in
+// the same zero context format used to check staged changes. Root commits are
+// diffed against the empty tree. Merge commits give an empty patch, as their
+// changes are covered by the commits being merged.
+func Patch(dir, rev string) ([]byte, error) {
+	return Run(dir, patchArgs(rev)...)
+}
+
+// StreamPatch is Patch, but the changes are passed to fn to be read as git
+// writes them rather than held in memory, as in StreamContext
+func StreamPatch(ctx context.Context, dir, rev string, fn func(r io.Reader) error) error {
+	return StreamContext(ctx, dir, patchArgs(rev), fn)
+}
+
+func patchArgs(rev string) []string {
+	return []string{"diff-tree", "-p", "-U0", "--root", "--no-commit-id", "--no-color", "--no-renames", rev}
+}
+
+// EmptyTree returns the ID of the empty tree object in the repository's hash
+// format. Diffing against it shows every file as added.
+func EmptyTree(dir string) (string, error) {
+	out, err := Run(dir, "hash-object", "-t", "tree", "--stdin")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// logMarker starts the header line of each commit in Log's output. Lines of a
+// patch always start with a diff marker, so can't be mistaken for it.
+const (
+	logMarker = "\x00commit\x00"
+	logFormat = "%x00commit%x00" + commitFormat
+)
+
+// Log streams the commits selected by the log arguments, e.g. "--all", calling
+// fn with each commit and a reader of the changes it introduced, in the same
+// format as Patch. The reader is only valid until fn returns, and any of the
+// patch fn doesn't read is skipped. Neither the whole log nor a whole commit is
+// ever held in memory, so it's suitable for very large repositories. If fn
+// returns an error, git is stopped and the error is returned.
+//
+// If git fails part way through, e.g. because it was interrupted, the patch of
+// the last commit passed to fn may be incomplete, and Log returns an error.
+func Log(dir string, args []string, fn func(c Commit, patch io.Reader) error) error {
+	args = append([]string{
+		"log", "-p", "-U0", "--no-color", "--no-renames", "--full-index", "--format=" + logFormat,
+	}, args...)
+	return Stream(dir, args, func(r io.Reader) error {
+		return readLog(bufio.NewReader(r), fn)
+	})
+}
+
+func readLog(r *bufio.Reader, fn func(c Commit, patch io.Reader) error) error {
+	for {
+		line, err := r.ReadBytes('\n')
+		if bytes.HasPrefix(line, []byte(logMarker)) {
+			c, err := parseCommit(strings.TrimRight(string(line[len(logMarker):]), "\n"))
+			if err != nil {
+				return err
+			}
+			patch := &logPatch{r: r}
+			if err := fn(c, patch); err != nil {
+				return err
+			}
+			if _, err := io.Copy(ioutil.Discard, patch); err != nil {
+				return err
+			}
+			continue
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// logPatch reads the patch of a single commit in Log's output, up to the
+// header line of the next commit
+type logPatch struct {
+	r    *bufio.Reader
+	line []byte // Rest of the current line
+	done bool
+}
+
+func (p *logPatch) Read(b []byte) (int, error) {
+	for len(p.line) == 0 {
+		if p.done {
+			return 0, io.EOF
+		}
+		if next, _ := p.r.Peek(len(logMarker)); bytes.Equal(next, []byte(logMarker)) {
+			p.done = true
+			continue
+		}
+		line, err := p.r.ReadBytes('\n')
+		if err == io.EOF {
+			p.done = true
+		} else if err != nil {
+			return 0, err
+		}
+		p.line = line
+	}
+	n := copy(b, p.line)
+	p.line = p.line[n:]
+	return n, nil
+}
+
+// Count returns the number of commits selected by the rev-list arguments
+func Count(dir string, args ...string) (int, error) {
+	out, err := Run(dir, append([]string{"rev-list", "--count"}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
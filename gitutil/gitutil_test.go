@@ -0,0 +1,232 @@
+package gitutil_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/git-diff-check/gitutil"
+)
+
+// newRepo creates a repository with two commits and returns its path and the
+// SHAs of the commits, oldest first
+func newRepo(t *testing.T) (string, []string) {
+	dir, err := ioutil.TempDir("", "gitutil")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=A Person", "GIT_AUTHOR_EMAIL=a.person@example.com",
+			"GIT_COMMITTER_NAME=A Person", "GIT_COMMITTER_EMAIL=a.person@example.com",
+			"GIT_AUTHOR_DATE=2020-06-18T10:00:00Z", "GIT_COMMITTER_DATE=2020-06-18T10:00:00Z",
+		)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v (%s)", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "-q")
+	write("a.txt", "one\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "First commit")
+	first := run("rev-parse", "HEAD")
+
+	write("a.txt", "one\ntwo\n")
+	write("b.txt", "new\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "Second commit")
+	second := run("rev-parse", "HEAD")
+
+	return dir, []string{first, second}
+}
+
+func TestRevList(t *testing.T) {
+	dir, shas := newRepo(t)
+	defer os.RemoveAll(dir)
+
+	got, err := gitutil.RevList(dir, "HEAD")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(got) != 2 || got[0] != shas[0] || got[1] != shas[1] {
+		t.Errorf("Expected %v oldest first, got %v", shas, got)
+	}
+
+	got, err = gitutil.RevList(dir, shas[0]+"..HEAD")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(got) != 1 || got[0] != shas[1] {
+		t.Errorf("Expected only %s, got %v", shas[1], got)
+	}
+
+	if _, err := gitutil.RevList(dir, "nonexistent..HEAD"); err == nil {
+		t.Error("Expected an error for a bad revision")
+	}
+}
+
+func TestShow(t *testing.T) {
+	dir, shas := newRepo(t)
+	defer os.RemoveAll(dir)
+
+	c, err := gitutil.Show(dir, shas[1])
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if c.SHA != shas[1] {
+		t.Errorf("Expected SHA %s, got %s", shas[1], c.SHA)
+	}
+	if c.Author != "A Person <a.person@example.com>" {
+		t.Errorf("Unexpected author %q", c.Author)
+	}
+	if c.Subject != "Second commit" {
+		t.Errorf("Unexpected subject %q", c.Subject)
+	}
+	if c.Date.Year() != 2020 {
+		t.Errorf("Unexpected date %v", c.Date)
+	}
+	if c.Short() != shas[1][:7] {
+		t.Errorf("Unexpected short SHA %s", c.Short())
+	}
+}
+
+func TestPatch(t *testing.T) {
+	dir, shas := newRepo(t)
+	defer os.RemoveAll(dir)
+
+	root, err := gitutil.Patch(dir, shas[0])
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !strings.Contains(string(root), "diff --git a/a.txt b/a.txt") || !strings.Contains(string(root), "+one") {
+		t.Errorf("Expected root commit to be diffed against the empty tree, got:\n%s", root)
+	}
+
+	patch, err := gitutil.Patch(dir, shas[1])
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	for _, expected := range []string{"diff --git a/a.txt b/a.txt", "@@ -1,0 +2 @@", "+two", "diff --git a/b.txt b/b.txt", "+new"} {
+		if !strings.Contains(string(patch), expected) {
+			t.Errorf("Expected patch to contain %q, got:\n%s", expected, patch)
+		}
+	}
+	if strings.Contains(string(patch), "+one") {
+		t.Errorf("Expected only the commit's own changes, got:\n%s", patch)
+	}
+}
+
+func TestStreamPatch(t *testing.T) {
+	dir, shas := newRepo(t)
+	defer os.RemoveAll(dir)
+
+	expected, err := gitutil.Patch(dir, shas[1])
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	var got []byte
+	err = gitutil.StreamPatch(context.Background(), dir, shas[1], func(r io.Reader) error {
+		got, err = ioutil.ReadAll(r)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if string(got) != string(expected) {
+		t.Errorf("Expected the same changes as Patch, got:\n%s", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = gitutil.StreamPatch(ctx, dir, shas[1], func(r io.Reader) error {
+		_, err := ioutil.ReadAll(r)
+		return err
+	})
+	if err == nil {
+		t.Error("Expected an error for a cancelled context")
+	}
+}
+
+func TestLog(t *testing.T) {
+	dir, shas := newRepo(t)
+	defer os.RemoveAll(dir)
+
+	var commits []gitutil.Commit
+	var patches []string
+	err := gitutil.Log(dir, []string{"--reverse"}, func(c gitutil.Commit, patch io.Reader) error {
+		b, err := ioutil.ReadAll(patch)
+		commits = append(commits, c)
+		patches = append(patches, string(b))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	if len(commits) != 2 || commits[0].SHA != shas[0] || commits[1].SHA != shas[1] {
+		t.Fatalf("Expected commits %v oldest first, got %v", shas, commits)
+	}
+	if commits[1].Subject != "Second commit" {
+		t.Errorf("Unexpected subject %q", commits[1].Subject)
+	}
+	if !strings.Contains(patches[0], "+one") || strings.Contains(patches[0], "+two") {
+		t.Errorf("Unexpected patch for first commit:\n%s", patches[0])
+	}
+	if !strings.Contains(patches[1], "+two") || !strings.Contains(patches[1], "diff --git a/b.txt b/b.txt") {
+		t.Errorf("Unexpected patch for second commit:\n%s", patches[1])
+	}
+
+	// Any of a patch that isn't read is skipped
+	commits = nil
+	err = gitutil.Log(dir, []string{"--reverse"}, func(c gitutil.Commit, patch io.Reader) error {
+		commits = append(commits, c)
+		_, err := patch.Read(make([]byte, 4))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(commits) != 2 || commits[1].SHA != shas[1] {
+		t.Errorf("Expected commits %v after partial reads, got %v", shas, commits)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = gitutil.Log(dir, nil, func(c gitutil.Commit, patch io.Reader) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("Expected log to stop after the first error, got %v after %d calls", err, calls)
+	}
+}
+
+func TestCount(t *testing.T) {
+	dir, _ := newRepo(t)
+	defer os.RemoveAll(dir)
+
+	n, err := gitutil.Count(dir, "--all")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 commits, got %d", n)
+	}
+}
@@ -0,0 +1,116 @@
+package gitutil
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime"
+	"net/mail"
+	"regexp"
+)
+
+// reFromLine matches the line that starts each message in an mbox, e.g. the
+// "From <sha> Mon Sep 17 00:00:00 2001" lines written by git format-patch
+var reFromLine = regexp.MustCompile(`^From (\S+) +\w{3} \w{3} +\d+ \d\d:\d\d:\d\d \d{4}$`)
+
+// reSHA matches a full commit SHA
+var reSHA = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+// rePatchPrefix matches the "[PATCH 1/2]" style prefix added to subjects
+var rePatchPrefix = regexp.MustCompile(`^\[[^\]]*PATCH[^\]]*\]\s*`)
+
+// Mail is a single patch from an mbox
+type Mail struct {
+	Commit Commit
+	Patch  []byte
+}
+
+// IsMbox reports whether the input is an mbox of patches, such as the output
+// of git format-patch, rather than a plain diff
+func IsMbox(b []byte) bool {
+	line := b
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		line = b[:i]
+	}
+	return reFromLine.Match(bytes.TrimRight(line, "\r"))
+}
+
+// SplitMbox splits an mbox of patches into its messages. The commit details
+// are taken from each message's From line and headers - SHA is only set if
+// the From line has one, as git format-patch output does.
+func SplitMbox(b []byte) ([]Mail, error) {
+	var sections [][]byte
+	start := -1
+	blank := true
+	for offset := 0; offset < len(b); {
+		end := bytes.IndexByte(b[offset:], '\n')
+		if end < 0 {
+			end = len(b)
+		} else {
+			end += offset + 1
+		}
+		line := bytes.TrimRight(b[offset:end], "\r\n")
+		if blank && reFromLine.Match(line) {
+			if start >= 0 {
+				sections = append(sections, b[start:offset])
+			}
+			start = offset
+		}
+		blank = len(bytes.TrimSpace(line)) == 0
+		offset = end
+	}
+	if start >= 0 {
+		sections = append(sections, b[start:])
+	}
+
+	mails := []Mail{}
+	for _, section := range sections {
+		m, err := parseMail(section)
+		if err != nil {
+			return nil, err
+		}
+		mails = append(mails, m)
+	}
+	return mails, nil
+}
+
+// parseMail parses a single message, starting with its From line
+func parseMail(section []byte) (Mail, error) {
+	var c Commit
+
+	i := bytes.IndexByte(section, '\n')
+	if i < 0 {
+		return Mail{Commit: c}, nil
+	}
+	if m := reFromLine.FindSubmatch(bytes.TrimRight(section[:i], "\r")); m != nil && reSHA.Match(m[1]) {
+		c.SHA = string(m[1])
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(section[i+1:]))
+	if err != nil {
+		return Mail{}, err
+	}
+
+	dec := new(mime.WordDecoder)
+	if from, err := dec.DecodeHeader(msg.Header.Get("From")); err == nil {
+		c.Author = from
+	}
+	if subject, err := dec.DecodeHeader(msg.Header.Get("Subject")); err == nil {
+		c.Subject = rePatchPrefix.ReplaceAllString(subject, "")
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		c.Date = date
+	}
+
+	body, err := ioutil.ReadAll(msg.Body)
+	if err != nil {
+		return Mail{}, err
+	}
+
+	// Drop the signature with the git version, which would otherwise be
+	// read as part of the last hunk
+	if i := bytes.LastIndex(body, []byte("\n-- \n")); i >= 0 {
+		body = body[:i+1]
+	}
+
+	return Mail{Commit: c, Patch: body}, nil
+}
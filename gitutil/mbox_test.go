@@ -0,0 +1,90 @@
+package gitutil_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/git-diff-check/gitutil"
+)
+
+func TestSplitMboxFormatPatch(t *testing.T) {
+	dir, shas := newRepo(t)
+	defer os.RemoveAll(dir)
+
+	out, err := gitutil.Run(dir, "format-patch", "--stdout", "--root", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gitutil.IsMbox(out) {
+		t.Fatalf("Expected format-patch output to be an mbox:\n%s", out)
+	}
+
+	mails, err := gitutil.SplitMbox(out)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(mails) != 2 {
+		t.Fatalf("Expected 2 patches, got %d", len(mails))
+	}
+
+	m := mails[1]
+	if m.Commit.SHA != shas[1] {
+		t.Errorf("Expected SHA %s, got %s", shas[1], m.Commit.SHA)
+	}
+	if m.Commit.Subject != "Second commit" {
+		t.Errorf("Expected the [PATCH] prefix to be removed from the subject, got %q", m.Commit.Subject)
+	}
+	if m.Commit.Author != "A Person <a.person@example.com>" {
+		t.Errorf("Unexpected author %q", m.Commit.Author)
+	}
+	if m.Commit.Date.Year() != 2020 {
+		t.Errorf("Unexpected date %v", m.Commit.Date)
+	}
+	if !strings.Contains(string(m.Patch), "+two") || strings.Contains(string(mails[0].Patch), "+two") {
+		t.Errorf("Expected each patch to only contain its own changes, got:\n%s", m.Patch)
+	}
+	if strings.Contains(string(m.Patch), "\n-- \n") {
+		t.Errorf("Expected the signature to be removed, got:\n%s", m.Patch)
+	}
+}
+
+func TestSplitMbox(t *testing.T) {
+	input := "From someone@example.com Thu Jun 18 10:00:00 2020\r\n" +
+		"From: =?UTF-8?q?Zo=C3=AB?= <zoe@example.com>\r\n" +
+		"Subject: [PATCH v2] Add\r\n" +
+		" config\r\n" +
+		"\r\n" +
+		"From here it's the message body, not a new patch Thu Jun 18 10:00:00 2020\r\n" +
+		"---\r\n" +
+		"diff --git a/config.py b/config.py\r\n" +
+		"+key = 1\r\n"
+
+	if !gitutil.IsMbox([]byte(input)) {
+		t.Fatal("Expected input to be an mbox")
+	}
+	mails, err := gitutil.SplitMbox([]byte(input))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(mails) != 1 {
+		t.Fatalf("Expected 1 patch, got %d", len(mails))
+	}
+	c := mails[0].Commit
+	if c.SHA != "" {
+		t.Errorf("Expected no SHA, got %q", c.SHA)
+	}
+	if c.Author != "Zoë <zoe@example.com>" {
+		t.Errorf("Expected the author to be decoded, got %q", c.Author)
+	}
+	if c.Subject != "Add config" {
+		t.Errorf("Expected the subject to be unfolded, got %q", c.Subject)
+	}
+	if !strings.Contains(string(mails[0].Patch), "+key = 1") {
+		t.Errorf("Unexpected patch:\n%s", mails[0].Patch)
+	}
+
+	if gitutil.IsMbox([]byte("diff --git a/a.txt b/a.txt\n+one\n")) {
+		t.Error("Expected a plain diff not to be an mbox")
+	}
+}
@@ -0,0 +1,157 @@
+package gitutil
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// IsZero reports whether sha is git's all zeros object ID, which is used for
+// a ref that doesn't exist, e.g. the remote side of a new branch
+func IsZero(sha string) bool {
+	return sha != "" && strings.Trim(sha, "0") == ""
+}
+
+// PushRef is a ref being pushed, as described to a pre-push hook on stdin
+type PushRef struct {
+	LocalRef  string
+	LocalSHA  string
+	RemoteRef string
+	RemoteSHA string
+}
+
+// Deleted reports whether the push deletes the remote ref
+func (r PushRef) Deleted() bool {
+	return IsZero(r.LocalSHA)
+}
+
+// Created reports whether the push creates the remote ref
+func (r PushRef) Created() bool {
+	return IsZero(r.RemoteSHA)
+}
+
+// ParsePushRefs reads the "<local ref> <local sha> <remote ref> <remote sha>"
+// lines given to a pre-push hook
+func ParsePushRefs(r io.Reader) ([]PushRef, error) {
+	refs := []PushRef{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("line %d: expected <local ref> <local sha> <remote ref> <remote sha>, got %q", n, line)
+		}
+		refs = append(refs, PushRef{LocalRef: fields[0], LocalSHA: fields[1], RemoteRef: fields[2], RemoteSHA: fields[3]})
+	}
+	return refs, scanner.Err()
+}
+
+// Outgoing returns the SHAs of the commits that pushing ref to remote would
+// send, oldest first. Commits already on any of the remote's tracking branches
+// are excluded, so a new branch only includes its own commits. When remote is
+// a URL rather than a configured remote, it has no tracking branches, so
+// commits on any remote's tracking branches are excluded instead. For a force
+// push only the commits that aren't already on the remote are included.
+// Returns nothing for a deleted ref.
+func Outgoing(dir, remote string, ref PushRef) ([]string, error) {
+	if ref.Deleted() {
+		return nil, nil
+	}
+
+	known, err := isRemote(dir, remote)
+	if err != nil {
+		return nil, err
+	}
+	remotes := "--remotes"
+	if known {
+		remotes = "--remotes=" + remote
+	}
+	args := []string{ref.LocalSHA, "--not", remotes}
+
+	// The remote ref may have been updated by someone else since it was last
+	// fetched, in which case it isn't known locally
+	if !ref.Created() && Exists(dir, ref.RemoteSHA) {
+		args = append(args, ref.RemoteSHA)
+	}
+	return RevList(dir, args...)
+}
+
+// isRemote reports whether name is one of the repository's configured remotes
+func isRemote(dir, name string) (bool, error) {
+	out, err := Run(dir, "remote")
+	if err != nil {
+		return false, err
+	}
+	for _, remote := range strings.Fields(string(out)) {
+		if remote == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Exists reports whether sha is a commit in the repository
+func Exists(dir, sha string) bool {
+	_, err := Run(dir, "cat-file", "-e", sha+"^{commit}")
+	return err == nil
+}
+
+// ReceiveRef is a ref being updated by a push, as described to a pre-receive
+// hook on stdin
+type ReceiveRef struct {
+	OldSHA string
+	NewSHA string
+	Ref    string
+}
+
+// Deleted reports whether the push deletes the ref
+func (r ReceiveRef) Deleted() bool {
+	return IsZero(r.NewSHA)
+}
+
+// Created reports whether the push creates the ref
+func (r ReceiveRef) Created() bool {
+	return IsZero(r.OldSHA)
+}
+
+// ParseReceiveRefs reads the "<old sha> <new sha> <ref>" lines given to a
+// pre-receive hook
+func ParseReceiveRefs(r io.Reader) ([]ReceiveRef, error) {
+	refs := []ReceiveRef{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected <old sha> <new sha> <ref>, got %q", n, line)
+		}
+		refs = append(refs, ReceiveRef{OldSHA: fields[0], NewSHA: fields[1], Ref: fields[2]})
+	}
+	return refs, scanner.Err()
+}
+
+// Incoming returns the SHAs of the commits that a push adds to the repository
+// at dir by updating ref, oldest first. As the refs haven't been updated yet,
+// any commit reachable from an existing ref is excluded - so only the commits
+// new to the repository are included, for new refs and force pushes as well.
+// Returns nothing for a deleted ref.
+func Incoming(dir string, ref ReceiveRef) ([]string, error) {
+	return IncomingContext(context.Background(), dir, ref)
+}
+
+// IncomingContext is Incoming, but git is killed if ctx is done before it
+// finishes
+func IncomingContext(ctx context.Context, dir string, ref ReceiveRef) ([]string, error) {
+	if ref.Deleted() {
+		return nil, nil
+	}
+	return RevListContext(ctx, dir, ref.NewSHA, "--not", "--all")
+}
@@ -0,0 +1,187 @@
+package gitutil_test
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/git-diff-check/gitutil"
+)
+
+const zero = "0000000000000000000000000000000000000000"
+
+func TestParsePushRefs(t *testing.T) {
+	input := "refs/heads/main 1111111111111111111111111111111111111111 refs/heads/main 2222222222222222222222222222222222222222\n" +
+		"\n" +
+		"refs/heads/new 3333333333333333333333333333333333333333 refs/heads/new " + zero + "\n" +
+		"(delete) " + zero + " refs/heads/old 4444444444444444444444444444444444444444\n"
+
+	refs, err := gitutil.ParsePushRefs(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(refs) != 3 {
+		t.Fatalf("Expected 3 refs, got %d: %+v", len(refs), refs)
+	}
+
+	testCases := []struct {
+		ref              gitutil.PushRef
+		created, deleted bool
+	}{
+		{refs[0], false, false},
+		{refs[1], true, false},
+		{refs[2], false, true},
+	}
+	for _, tc := range testCases {
+		if tc.ref.Created() != tc.created || tc.ref.Deleted() != tc.deleted {
+			t.Errorf("Expected %+v to have created %v and deleted %v", tc.ref, tc.created, tc.deleted)
+		}
+	}
+	if refs[0].LocalRef != "refs/heads/main" || refs[0].RemoteSHA != "2222222222222222222222222222222222222222" {
+		t.Errorf("Unexpected ref %+v", refs[0])
+	}
+
+	if _, err := gitutil.ParsePushRefs(strings.NewReader("refs/heads/main 1111\n")); err == nil {
+		t.Error("Expected an error for a short line")
+	}
+}
+
+func TestOutgoing(t *testing.T) {
+	dir, shas := newRepo(t)
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		name     string
+		ref      gitutil.PushRef
+		expected []string
+	}{
+		{
+			name:     "update",
+			ref:      gitutil.PushRef{LocalSHA: shas[1], RemoteSHA: shas[0]},
+			expected: shas[1:],
+		},
+		{
+			name:     "new branch",
+			ref:      gitutil.PushRef{LocalSHA: shas[1], RemoteSHA: zero},
+			expected: shas,
+		},
+		{
+			name:     "remote commit not known locally",
+			ref:      gitutil.PushRef{LocalSHA: shas[1], RemoteSHA: "1234567890123456789012345678901234567890"},
+			expected: shas,
+		},
+		{
+			name:     "force push",
+			ref:      gitutil.PushRef{LocalSHA: shas[0], RemoteSHA: shas[1]},
+			expected: []string{},
+		},
+		{
+			name: "delete",
+			ref:  gitutil.PushRef{LocalSHA: zero, RemoteSHA: shas[1]},
+		},
+	}
+
+	for _, tc := range testCases {
+		got, err := gitutil.Outgoing(dir, "origin", tc.ref)
+		if err != nil {
+			t.Errorf("%s: expected no error, but got: %v", tc.name, err)
+			continue
+		}
+		if strings.Join(got, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestOutgoingRemotes(t *testing.T) {
+	dir, shas := newRepo(t)
+	defer os.RemoveAll(dir)
+
+	// The first commit is already on upstream, and nothing has been fetched
+	// from other
+	for _, args := range [][]string{
+		{"remote", "add", "upstream", "https://example.com/upstream.git"},
+		{"remote", "add", "other", "https://example.com/other.git"},
+		{"update-ref", "refs/remotes/upstream/master", shas[0]},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v (%s)", args, err, out)
+		}
+	}
+
+	testCases := []struct {
+		remote   string
+		expected []string
+	}{
+		{remote: "upstream", expected: shas[1:]},
+		{remote: "other", expected: shas},
+		{remote: "https://example.com/upstream.git", expected: shas[1:]},
+		{remote: "../upstream", expected: shas[1:]},
+	}
+
+	ref := gitutil.PushRef{LocalSHA: shas[1], RemoteSHA: zero}
+	for _, tc := range testCases {
+		got, err := gitutil.Outgoing(dir, tc.remote, ref)
+		if err != nil {
+			t.Errorf("%s: expected no error, but got: %v", tc.remote, err)
+			continue
+		}
+		if strings.Join(got, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("%s: expected %v, got %v", tc.remote, tc.expected, got)
+		}
+	}
+}
+
+func TestParseReceiveRefs(t *testing.T) {
+	input := "1111111111111111111111111111111111111111 2222222222222222222222222222222222222222 refs/heads/main\n" +
+		zero + " 3333333333333333333333333333333333333333 refs/heads/new\n" +
+		"4444444444444444444444444444444444444444 " + zero + " refs/heads/old\n"
+
+	refs, err := gitutil.ParseReceiveRefs(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(refs) != 3 {
+		t.Fatalf("Expected 3 refs, got %d: %+v", len(refs), refs)
+	}
+	if refs[0].Ref != "refs/heads/main" || refs[0].Created() || refs[0].Deleted() {
+		t.Errorf("Unexpected ref %+v", refs[0])
+	}
+	if !refs[1].Created() || refs[1].Deleted() {
+		t.Errorf("Expected %+v to be created", refs[1])
+	}
+	if refs[2].Created() || !refs[2].Deleted() {
+		t.Errorf("Expected %+v to be deleted", refs[2])
+	}
+
+	if _, err := gitutil.ParseReceiveRefs(strings.NewReader("1111 2222\n")); err == nil {
+		t.Error("Expected an error for a short line")
+	}
+}
+
+func TestIncoming(t *testing.T) {
+	dir, shas := newRepo(t)
+	defer os.RemoveAll(dir)
+
+	// Pretend the repository only has the first commit so far
+	if _, err := gitutil.Run(dir, "update-ref", "refs/heads/server", shas[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gitutil.Run(dir, "update-ref", "-d", "HEAD"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := gitutil.Incoming(dir, gitutil.ReceiveRef{OldSHA: zero, NewSHA: shas[1], Ref: "refs/heads/new"})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(got) != 1 || got[0] != shas[1] {
+		t.Errorf("Expected only %s, got %v", shas[1], got)
+	}
+
+	got, err = gitutil.Incoming(dir, gitutil.ReceiveRef{OldSHA: shas[1], NewSHA: zero, Ref: "refs/heads/old"})
+	if err != nil || len(got) != 0 {
+		t.Errorf("Expected nothing for a deleted ref, got %v (%v)", got, err)
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/ONSdigital/git-diff-check
 
 go 1.14
+
+require gopkg.in/yaml.v2 v2.4.0
@@ -0,0 +1,340 @@
+// Package history checks every commit in a repository's history, e.g. to
+// audit an existing repository before adopting the pre-commit hook.
+//
+// Findings are deduplicated so that each is reported once, against the commit
+// that first introduced it. A file version (blob) that has already been checked
+// is skipped wherever it appears again, and a finding with the same fingerprint
+// as an earlier one isn't reported again.
+//
+// Progress can be saved to a checkpoint file and resumed later, as checking a
+// large repository can take some time.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/ONSdigital/git-diff-check/diffcheck"
+	"github.com/ONSdigital/git-diff-check/gitutil"
+)
+
+// CheckpointVersion is the version of the checkpoint file format
+const CheckpointVersion = 1
+
+// ErrStopped is returned by Run when it's stopped before checking every commit
+var ErrStopped = errors.New("history scan stopped")
+
+// Result holds the findings first introduced by a commit
+type Result struct {
+	Commit  gitutil.Commit
+	Reports []diffcheck.Report
+}
+
+// Scan is the state of a history scan
+type Scan struct {
+	// Results for each commit that introduced a finding, oldest first
+	Results []Result
+
+	commits      map[string]bool
+	blobs        map[string]bool
+	fingerprints map[string]bool
+}
+
+// checkpoint is the saved form of a Scan
+type checkpoint struct {
+	Version      int      `json:"version"`
+	Commits      []string `json:"commits"`
+	Blobs        []string `json:"blobs"`
+	Fingerprints []string `json:"fingerprints"`
+	Results      []Result `json:"results"`
+}
+
+// New returns a Scan that hasn't checked anything yet
+func New() *Scan {
+	return &Scan{
+		Results:      []Result{},
+		commits:      map[string]bool{},
+		blobs:        map[string]bool{},
+		fingerprints: map[string]bool{},
+	}
+}
+
+// Resume loads a Scan from the checkpoint file at path
+func Resume(path string) (*Scan, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if cp.Version != CheckpointVersion {
+		return nil, fmt.Errorf("%s: unsupported checkpoint version %d", path, cp.Version)
+	}
+
+	s := New()
+	if cp.Results != nil {
+		s.Results = cp.Results
+	}
+	for _, sha := range cp.Commits {
+		s.commits[sha] = true
+	}
+	for _, blob := range cp.Blobs {
+		s.blobs[blob] = true
+	}
+	for _, fp := range cp.Fingerprints {
+		s.fingerprints[fp] = true
+	}
+	return s, nil
+}
+
+// Save writes a checkpoint of the Scan to path
+func (s *Scan) Save(path string) error {
+	cp := checkpoint{
+		Version:      CheckpointVersion,
+		Commits:      keys(s.commits),
+		Blobs:        keys(s.blobs),
+		Fingerprints: keys(s.fingerprints),
+		Results:      s.Results,
+	}
+	out, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, out, 0644)
+}
+
+// Checked returns the number of commits checked so far
+func (s *Scan) Checked() int {
+	return len(s.commits)
+}
+
+// Run checks every commit reachable from any ref in the repository at dir,
+// skipping any already checked by a resumed Scan. progress, if not nil, is
+// called after each commit. If stop is closed, Run returns ErrStopped after the
+// current commit, and can be resumed from a checkpoint.
+func (s *Scan) Run(dir string, progress func(), stop <-chan struct{}) error {
+	// Oldest first, with parents always before their children, so that
+	// findings are reported against the commit that introduced them
+	args := []string{"--all", "--reverse", "--topo-order"}
+
+	// A commit's patch is only known to be complete once the next commit
+	// starts, or git exits cleanly. If git is killed part way through, e.g. by
+	// the same interrupt that closes stop, the last commit must not be recorded
+	// as checked.
+	var pending *commitScan
+	record := func() {
+		if pending == nil {
+			return
+		}
+		s.record(pending)
+		pending = nil
+		if progress != nil {
+			progress()
+		}
+	}
+
+	err := gitutil.Log(dir, args, func(c gitutil.Commit, patch io.Reader) error {
+		record()
+		select {
+		case <-stop:
+			return ErrStopped
+		default:
+		}
+
+		if s.commits[c.SHA] {
+			return nil
+		}
+		cs, err := s.check(c, patch)
+		if err != nil {
+			return fmt.Errorf("commit %s: %v", c.SHA, err)
+		}
+		pending = cs
+		return nil
+	})
+	if err != nil {
+		select {
+		case <-stop:
+			return ErrStopped
+		default:
+		}
+		return err
+	}
+	record()
+	return nil
+}
+
+// commitScan holds what was found checking a commit, until the commit is
+// recorded as checked
+type commitScan struct {
+	sha          string
+	result       Result
+	blobs        map[string]bool
+	fingerprints map[string]bool
+}
+
+// check runs the diff check on the file versions in the commit's patch that
+// haven't been seen before, and returns any new findings
+func (s *Scan) check(c gitutil.Commit, patch io.Reader) (*commitScan, error) {
+	cs := &commitScan{
+		sha:          c.SHA,
+		result:       Result{Commit: c},
+		blobs:        map[string]bool{},
+		fingerprints: map[string]bool{},
+	}
+	unseen := &unseenFiles{
+		r: bufio.NewReader(patch),
+		seen: func(blob string) bool {
+			if s.blobs[blob] || cs.blobs[blob] {
+				return true
+			}
+			cs.blobs[blob] = true
+			return false
+		},
+	}
+
+	_, err := diffcheck.Scan(context.Background(), unseen, diffcheck.ScanOptions{
+		Report: func(report diffcheck.Report) error {
+			report.Warnings = s.dedupe(cs, report.Warnings)
+			report.Suppressed = s.dedupe(cs, report.Suppressed)
+			if len(report.Warnings) > 0 || len(report.Suppressed) > 0 {
+				cs.result.Reports = append(cs.result.Reports, report)
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// record marks a checked commit, and adds what was found checking it to the
+// Scan
+func (s *Scan) record(cs *commitScan) {
+	s.commits[cs.sha] = true
+	for blob := range cs.blobs {
+		s.blobs[blob] = true
+	}
+	for fp := range cs.fingerprints {
+		s.fingerprints[fp] = true
+	}
+	if len(cs.result.Reports) > 0 {
+		s.Results = append(s.Results, cs.result)
+	}
+}
+
+// dedupe removes any warnings that have been found before, and adds the rest
+// to the commit's fingerprints
+func (s *Scan) dedupe(cs *commitScan, warnings []diffcheck.Warning) []diffcheck.Warning {
+	out := []diffcheck.Warning{}
+	for _, w := range warnings {
+		if s.fingerprints[w.Fingerprint] || cs.fingerprints[w.Fingerprint] {
+			continue
+		}
+		cs.fingerprints[w.Fingerprint] = true
+		out = append(out, w)
+	}
+	return out
+}
+
+// unseenFiles reads a patch, skipping the blocks of any files whose new
+// version has been seen before
+type unseenFiles struct {
+	r    *bufio.Reader
+	seen func(blob string) bool // Reports whether blob was seen, and marks it seen
+
+	header []byte // Header of the current file, until its first hunk
+	skip   bool   // Whether the current file is skipped
+	out    []byte // Rest of the patch read but not yet returned
+	err    error
+}
+
+func (u *unseenFiles) Read(b []byte) (int, error) {
+	for len(u.out) == 0 {
+		if u.err != nil {
+			return 0, u.err
+		}
+		line, err := u.r.ReadBytes('\n')
+		u.add(line)
+		if err != nil {
+			u.endHeader()
+			u.err = err
+		}
+	}
+	n := copy(b, u.out)
+	u.out = u.out[n:]
+	return n, nil
+}
+
+// add handles the next line of the patch
+func (u *unseenFiles) add(line []byte) {
+	isDiff := bytes.HasPrefix(line, []byte("diff --git "))
+	if u.header != nil && !isDiff && !bytes.HasPrefix(line, []byte("@@")) {
+		u.header = append(u.header, line...)
+		return
+	}
+	u.endHeader()
+	switch {
+	case isDiff:
+		u.header = line
+	case !u.skip:
+		u.out = append(u.out, line...)
+	}
+}
+
+// endHeader decides whether to skip the current file, once its header has been
+// read
+func (u *unseenFiles) endHeader() {
+	if u.header == nil {
+		return
+	}
+	blob := blobID(u.header)
+	u.skip = blob != "" && u.seen(blob)
+	if !u.skip {
+		u.out = append(u.out, u.header...)
+	}
+	u.header = nil
+}
+
+// blobID returns the ID of the new version of the file in a patch block, from
+// its "index <old>..<new>" line. Returns "" if the block has no index line, or
+// the file was deleted.
+func blobID(block []byte) string {
+	for _, line := range bytes.Split(block, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte("@@")) {
+			break
+		}
+		if !bytes.HasPrefix(line, []byte("index ")) {
+			continue
+		}
+		ids := bytes.Fields(line[len("index "):])
+		if len(ids) == 0 {
+			return ""
+		}
+		parts := bytes.SplitN(ids[0], []byte(".."), 2)
+		if len(parts) != 2 || len(bytes.Trim(parts[1], "0")) == 0 {
+			return ""
+		}
+		return string(parts[1])
+	}
+	return ""
+}
+
+func keys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,269 @@
+// Package ignore matches file paths against gitignore style patterns so that
+// files can be excluded from a diff check.
+//
+// Patterns follow the gitignore syntax:
+//
+//	# comment
+//	*.csv              any file called *.csv, at any depth
+//	/build             build at the top of the repository only
+//	testdata/          anything within any directory called testdata
+//	docs/**/*.md       markdown anywhere below docs
+//	!docs/keep.md      re-include a previously ignored file
+//
+// Unlike git, a file can be re-included even if its parent directory has been
+// ignored.
+//
+// A pattern may also be prefixed with `file:` or `line:` to only skip the
+// filename rules or the line rules for matching files, e.g. `line:*.lock`
+// still checks for sensitive lock file names, but doesn't check their content.
+// Patterns without a prefix skip both.
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Scope identifies which rulesets are skipped for a file
+type Scope int
+
+// Available scopes. These can be combined.
+const (
+	File Scope = 1 << iota // Filename rules
+	Line                   // Line rules
+
+	None Scope = 0
+	All        = File | Line
+)
+
+func (s Scope) String() string {
+	switch s {
+	case None:
+		return "none"
+	case File:
+		return "file"
+	case Line:
+		return "line"
+	case All:
+		return "all"
+	}
+	return fmt.Sprintf("Scope(%d)", int(s))
+}
+
+// List is an ordered set of ignore patterns. As with gitignore, where several
+// patterns match a path the last one takes precedence.
+type List struct {
+	patterns []pattern
+}
+
+type pattern struct {
+	scope   Scope
+	negate  bool
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+// Parse reads patterns from r, one per line
+func Parse(r io.Reader) (*List, error) {
+	l := &List{}
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		n++
+		if err := l.Add(scanner.Text()); err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+	}
+	return l, scanner.Err()
+}
+
+// ReadFile reads patterns from the file at path. A missing file is treated as
+// an empty list.
+func ReadFile(path string) (*List, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return &List{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	l, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return l, nil
+}
+
+// Add appends a single pattern to the list. Blank lines and comments are
+// accepted and ignored.
+func (l *List) Add(line string) error {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	p := pattern{scope: All}
+
+	// Negation may be given either side of the scope, i.e. `!line:x` or
+	// `line:!x`
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	}
+
+	switch {
+	case strings.HasPrefix(line, "file:"):
+		p.scope = File
+		line = line[len("file:"):]
+	case strings.HasPrefix(line, "line:"):
+		p.scope = Line
+		line = line[len("line:"):]
+	}
+
+	if !p.negate && strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	// A slash anywhere but the end anchors the pattern to the repository
+	// root, otherwise it can match at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return fmt.Errorf("empty pattern")
+	}
+
+	expr, err := translate(line)
+	if err != nil {
+		return err
+	}
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(?:.*/)?" + expr + "$"
+	}
+
+	p.re, err = regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+
+	l.patterns = append(l.patterns, p)
+	return nil
+}
+
+// Match returns the scopes for which the file at path, relative to the
+// repository root, is ignored. A nil List ignores nothing.
+func (l *List) Match(path string) Scope {
+	if l == nil {
+		return None
+	}
+
+	dirs := parents(path)
+
+	ignored := None
+	for _, p := range l.patterns {
+		if !p.matches(path, dirs) {
+			continue
+		}
+		if p.negate {
+			ignored &^= p.scope
+		} else {
+			ignored |= p.scope
+		}
+	}
+	return ignored
+}
+
+// Append adds all of the patterns from o to the end of l
+func (l *List) Append(o *List) {
+	if o != nil {
+		l.patterns = append(l.patterns, o.patterns...)
+	}
+}
+
+func (p pattern) matches(path string, dirs []string) bool {
+	if !p.dirOnly && p.re.MatchString(path) {
+		return true
+	}
+	for _, d := range dirs {
+		if p.re.MatchString(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// parents returns each of the directories containing path, e.g. a/b/c.txt
+// gives a and a/b
+func parents(path string) []string {
+	var dirs []string
+	for i, c := range path {
+		if c == '/' && i > 0 {
+			dirs = append(dirs, path[:i])
+		}
+	}
+	return dirs
+}
+
+// translate converts a glob into the equivalent regular expression
+func translate(glob string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**") {
+				atStart := i == 0 || glob[i-1] == '/'
+				rest := glob[i+2:]
+				switch {
+				case atStart && strings.HasPrefix(rest, "/"):
+					// `**/` matches zero or more directories
+					b.WriteString("(?:.*/)?")
+					i += 2
+				case atStart && rest == "":
+					// trailing `/**` matches everything inside
+					b.WriteString(".*")
+					i++
+				default:
+					b.WriteString("[^/]*")
+					i++
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated character class in %q", glob)
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String(), nil
+}
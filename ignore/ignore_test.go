@@ -0,0 +1,98 @@
+package ignore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/git-diff-check/ignore"
+)
+
+var patterns = `
+# Generated and vendored code
+vendor/
+*.min.js
+/build
+docs/**/*.md
+!docs/keep/*.md
+testdata/**
+**/fixtures/*.json
+data?.csv
+report[0-9].txt
+\#notacomment
+\!bang
+
+# Scoped patterns
+line:*.lock
+file:secrets/example.env
+line:scratch/
+!line:scratch/notes.txt
+`
+
+var matchCases = []struct {
+	Path     string
+	Expected ignore.Scope
+}{
+	{"main.go", ignore.None},
+	{"vendor/github.com/x/y.go", ignore.All},
+	{"src/vendor/z.go", ignore.All},
+	{"vendor", ignore.None},
+	{"static/app.min.js", ignore.All},
+	{"build/output.bin", ignore.All},
+	{"src/build/output.bin", ignore.None},
+	{"docs/guide.md", ignore.All},
+	{"docs/a/b/guide.md", ignore.All},
+	{"docs/keep/guide.md", ignore.None},
+	{"testdata/deep/file.pem", ignore.All},
+	{"fixtures/user.json", ignore.All},
+	{"a/b/fixtures/user.json", ignore.All},
+	{"a/b/fixtures/user.yml", ignore.None},
+	{"data1.csv", ignore.All},
+	{"data10.csv", ignore.None},
+	{"report3.txt", ignore.All},
+	{"reportX.txt", ignore.None},
+	{"#notacomment", ignore.All},
+	{"!bang", ignore.All},
+	{"go.lock", ignore.Line},
+	{"secrets/example.env", ignore.File},
+	{"other/secrets/example.env", ignore.None},
+	{"scratch/keys.txt", ignore.Line},
+	{"scratch/notes.txt", ignore.None},
+}
+
+func TestMatch(t *testing.T) {
+	l, err := ignore.Parse(strings.NewReader(patterns))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	for _, tc := range matchCases {
+		if got := l.Match(tc.Path); got != tc.Expected {
+			t.Errorf("%s: expected %s, got %s", tc.Path, tc.Expected, got)
+		}
+	}
+}
+
+func TestMatchLastPatternWins(t *testing.T) {
+	l := &ignore.List{}
+	for _, p := range []string{"*.txt", "!important.txt", "important.txt"} {
+		if err := l.Add(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := l.Match("important.txt"); got != ignore.All {
+		t.Errorf("Expected the last pattern to take precedence, got %s", got)
+	}
+}
+
+func TestNilList(t *testing.T) {
+	var l *ignore.List
+	if got := l.Match("anything"); got != ignore.None {
+		t.Errorf("Expected a nil list to ignore nothing, got %s", got)
+	}
+}
+
+func TestInvalidPattern(t *testing.T) {
+	if _, err := ignore.Parse(strings.NewReader("ok\nbad[0-9\n")); err == nil {
+		t.Error("Expected an error for an unterminated character class")
+	}
+}
@@ -58,6 +58,13 @@ curl -L --progress-bar -f ${release} -o "${target}/pre-commit"
 
 chmod +x "${target}/pre-commit"
 
+# The same binary checks outgoing commits when run as the pre-push hook
+[ ! -e ${target}/pre-push ] &&
+  {
+    echo "Linking pre-push hook ..."
+    ln -s pre-commit "${target}/pre-push"
+  }
+
 # Update the git config
 echo "Updating git config ..."
 git config --global core.hooksPath ${target}
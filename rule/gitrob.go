@@ -1,561 +1,877 @@
 package rule
 
-// Taken from most recent release of gitrob
+// Taken from most recent release of gitrob, with remediation added
 // https://raw.githubusercontent.com/michenriksen/gitrob/master/signatures.json
 var gitrobJSON = []byte(`
 [
 	{
+	  "id": "private-ssh-key-rsa",
+	  "severity": "high",
+	  "tags": ["key", "ssh"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A.*_rsa\\z",
 	  "caption": "Private SSH key",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the key and generate a new key pair - the old one should be treated as compromised"
 	},
 	{
+	  "id": "private-ssh-key-dsa",
+	  "severity": "high",
+	  "tags": ["key", "ssh"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A.*_dsa\\z",
 	  "caption": "Private SSH key",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the key and generate a new key pair - the old one should be treated as compromised"
 	},
 	{
+	  "id": "private-ssh-key-ed25519",
+	  "severity": "high",
+	  "tags": ["key", "ssh"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A.*_ed25519\\z",
 	  "caption": "Private SSH key",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the key and generate a new key pair - the old one should be treated as compromised"
 	},
 	{
+	  "id": "private-ssh-key-ecdsa",
+	  "severity": "high",
+	  "tags": ["key", "ssh"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A.*_ecdsa\\z",
 	  "caption": "Private SSH key",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the key and generate a new key pair - the old one should be treated as compromised"
 	},
 	{
+	  "id": "ssh-config",
+	  "severity": "medium",
+	  "tags": ["config", "ssh"],
 	  "part": "path",
 	  "type": "regex",
 	  "pattern": "\\.?ssh/config\\z",
 	  "caption": "SSH configuration file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file, or commit a copy without host names, users or key paths"
 	},
 	{
+	  "id": "pem-file",
+	  "severity": "high",
+	  "tags": ["key"],
 	  "part": "extension",
 	  "type": "match",
 	  "pattern": "pem",
 	  "caption": "Potential cryptographic private key",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file, and if it holds a private key generate a new one - the old one should be treated as compromised"
 	},
 	{
+	  "id": "key-file",
+	  "severity": "high",
+	  "tags": ["key"],
 	  "part": "extension",
 	  "type": "regex",
 	  "pattern": "\\Akey(pair)?\\z",
 	  "caption": "Potential cryptographic private key",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file, and if it holds a private key generate a new one - the old one should be treated as compromised"
 	},
 	{
+	  "id": "pkcs12-bundle",
+	  "severity": "high",
+	  "tags": ["key"],
 	  "part": "extension",
 	  "type": "match",
 	  "pattern": "pkcs12",
 	  "caption": "Potential cryptographic key bundle",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the bundle and reissue the certificate with a new private key"
 	},
 	{
+	  "id": "pfx-bundle",
+	  "severity": "high",
+	  "tags": ["key"],
 	  "part": "extension",
 	  "type": "match",
 	  "pattern": "pfx",
 	  "caption": "Potential cryptographic key bundle",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the bundle and reissue the certificate with a new private key"
 	},
 	{
+	  "id": "p12-bundle",
+	  "severity": "high",
+	  "tags": ["key"],
 	  "part": "extension",
 	  "type": "match",
 	  "pattern": "p12",
 	  "caption": "Potential cryptographic key bundle",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the bundle and reissue the certificate with a new private key"
 	},
 	{
+	  "id": "asc-key-bundle",
+	  "severity": "high",
+	  "tags": ["key"],
 	  "part": "extension",
 	  "type": "match",
 	  "pattern": "asc",
 	  "caption": "Potential cryptographic key bundle",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file, and if it holds a private key revoke it and generate a new one"
 	},
 	{
+	  "id": "pidgin-otr-private-key",
+	  "severity": "high",
+	  "tags": ["key"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "otr.private_key",
 	  "caption": "Pidgin OTR private key",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file and generate a new OTR key"
 	},
 	{
+	  "id": "shell-history",
+	  "severity": "medium",
+	  "tags": ["history"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?(bash_|zsh_|z)?history\\z",
 	  "caption": "Shell command history file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the history file and change any passwords or tokens typed on the command line"
 	},
 	{
+	  "id": "mysql-history",
+	  "severity": "medium",
+	  "tags": ["history", "database"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?mysql_history\\z",
 	  "caption": "MySQL client command history file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the history file and change any passwords or tokens typed on the command line"
 	},
 	{
+	  "id": "psql-history",
+	  "severity": "medium",
+	  "tags": ["history", "database"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?psql_history\\z",
 	  "caption": "PostgreSQL client command history file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the history file and change any passwords or tokens typed on the command line"
 	},
 	{
+	  "id": "pgpass",
+	  "severity": "high",
+	  "tags": ["credentials", "database"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?pgpass\\z",
 	  "caption": "PostgreSQL password file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file and change the database passwords in it"
 	},
 	{
+	  "id": "irb-history",
+	  "severity": "medium",
+	  "tags": ["history"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?irb_history\\z",
 	  "caption": "Ruby IRB console history file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the history file and change any passwords or tokens typed on the command line"
 	},
 	{
+	  "id": "pidgin-accounts",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "path",
 	  "type": "regex",
 	  "pattern": "\\.?purple\\/accounts\\.xml\\z",
 	  "caption": "Pidgin chat client account configuration file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file and change the chat account passwords in it"
 	},
 	{
+	  "id": "xchat-server-list",
+	  "severity": "medium",
+	  "tags": ["config"],
 	  "part": "path",
 	  "type": "regex",
 	  "pattern": "\\.?xchat2?\\/servlist_?\\.conf\\z",
 	  "caption": "Hexchat/XChat IRC client server list configuration file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file and change any server or NickServ passwords in it"
 	},
 	{
+	  "id": "irssi-config",
+	  "severity": "medium",
+	  "tags": ["config"],
 	  "part": "path",
 	  "type": "regex",
 	  "pattern": "\\.?irssi\\/config\\z",
 	  "caption": "Irssi IRC client configuration file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file and change any server or NickServ passwords in it"
 	},
 	{
+	  "id": "recon-ng-keys",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "path",
 	  "type": "regex",
 	  "pattern": "\\.?recon-ng\\/keys\\.db\\z",
 	  "caption": "Recon-ng web reconnaissance framework API key database",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the database and revoke the API keys in it"
 	},
 	{
+	  "id": "dbeaver-data-sources",
+	  "severity": "high",
+	  "tags": ["credentials", "database"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?dbeaver-data-sources.xml\\z",
 	  "caption": "DBeaver SQL database manager configuration file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file and change any saved database passwords"
 	},
 	{
+	  "id": "muttrc",
+	  "severity": "medium",
+	  "tags": ["config"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?muttrc\\z",
 	  "caption": "Mutt e-mail client configuration file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file and change any mail passwords in it"
 	},
 	{
+	  "id": "s3cmd-config",
+	  "severity": "high",
+	  "tags": ["credentials", "aws"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?s3cfg\\z",
 	  "caption": "S3cmd configuration file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file and deactivate the access key in IAM"
 	},
 	{
+	  "id": "aws-cli-credentials",
+	  "severity": "critical",
+	  "tags": ["credentials", "aws"],
 	  "part": "path",
 	  "type": "regex",
 	  "pattern": "\\.?aws/credentials\\z",
 	  "caption": "AWS CLI credentials file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file and deactivate the access keys in it in IAM"
 	},
 	{
+	  "id": "t-twitter-config",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?trc\\z",
 	  "caption": "T command-line Twitter client configuration file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file and revoke the application's access in Twitter"
 	},
 	{
+	  "id": "openvpn-config",
+	  "severity": "medium",
+	  "tags": ["config"],
 	  "part": "extension",
 	  "type": "match",
 	  "pattern": "ovpn",
 	  "caption": "OpenVPN client configuration file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file, and revoke and reissue any certificate or key embedded in it"
 	},
 	{
+	  "id": "gitrob-config",
+	  "severity": "medium",
+	  "tags": ["config"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?gitrobrc\\z",
 	  "caption": "Well, this is awkward... Gitrob configuration file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file and revoke the GitHub token in it"
 	},
 	{
+	  "id": "shell-rc",
+	  "severity": "low",
+	  "tags": ["config"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?(bash|zsh)rc\\z",
 	  "caption": "Shell configuration file",
-	  "description": "Shell configuration files might contain information such as server hostnames, passwords and API keys."
+	  "description": "Shell configuration files might contain information such as server hostnames, passwords and API keys.",
+	  "remediation": "Remove the file, or commit a copy without exported passwords or tokens"
 	},
 	{
+	  "id": "shell-profile",
+	  "severity": "low",
+	  "tags": ["config"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?(bash_|zsh_)?profile\\z",
 	  "caption": "Shell profile configuration file",
-	  "description": "Shell configuration files might contain information such as server hostnames, passwords and API keys."
+	  "description": "Shell configuration files might contain information such as server hostnames, passwords and API keys.",
+	  "remediation": "Remove the file, or commit a copy without exported passwords or tokens"
 	},
 	{
+	  "id": "shell-aliases",
+	  "severity": "low",
+	  "tags": ["config"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?(bash_|zsh_)?aliases\\z",
 	  "caption": "Shell command alias configuration file",
-	  "description": "Shell configuration files might contain information such as server hostnames, passwords and API keys."
+	  "description": "Shell configuration files might contain information such as server hostnames, passwords and API keys.",
+	  "remediation": "Remove the file, or commit a copy without passwords or tokens in the aliases"
 	},
 	{
+	  "id": "rails-secret-token",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "secret_token.rb",
 	  "caption": "Ruby On Rails secret token configuration file",
-	  "description": "If the Rails secret token is known, it can allow for remote code execution. (http://www.exploit-db.com/exploits/27527/)"
+	  "description": "If the Rails secret token is known, it can allow for remote code execution. (http://www.exploit-db.com/exploits/27527/)",
+	  "remediation": "Generate a new secret token and load it from the environment or Rails credentials instead"
 	},
 	{
+	  "id": "omniauth-config",
+	  "severity": "medium",
+	  "tags": ["config"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "omniauth.rb",
 	  "caption": "OmniAuth configuration file",
-	  "description": "The OmniAuth configuration file might contain client application secrets."
+	  "description": "The OmniAuth configuration file might contain client application secrets.",
+	  "remediation": "Revoke the client secrets in it and load them from the environment instead"
 	},
 	{
+	  "id": "carrierwave-config",
+	  "severity": "medium",
+	  "tags": ["config"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "carrierwave.rb",
 	  "caption": "Carrierwave configuration file",
-	  "description": "Can contain credentials for online storage systems such as Amazon S3 and Google Storage."
+	  "description": "Can contain credentials for online storage systems such as Amazon S3 and Google Storage.",
+	  "remediation": "Revoke the storage credentials in it and load them from the environment instead"
 	},
 	{
+	  "id": "rails-schema",
+	  "severity": "low",
+	  "tags": ["database"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "schema.rb",
 	  "caption": "Ruby On Rails database schema file",
-	  "description": "Contains information on the database schema of a Ruby On Rails application."
+	  "description": "Contains information on the database schema of a Ruby On Rails application.",
+	  "remediation": "Check the schema doesn't contain data or credentials, then add it to the allow list"
 	},
 	{
+	  "id": "rails-database-config",
+	  "severity": "medium",
+	  "tags": ["config", "database"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "database.yml",
 	  "caption": "Potential Ruby On Rails database configuration file",
-	  "description": "Might contain database credentials."
+	  "description": "Might contain database credentials.",
+	  "remediation": "Load the database password from the environment instead, e.g. with ERB in database.yml"
 	},
 	{
+	  "id": "django-settings",
+	  "severity": "medium",
+	  "tags": ["config"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "settings.py",
 	  "caption": "Django configuration file",
-	  "description": "Might contain database credentials, online storage system credentials, secret keys, etc."
+	  "description": "Might contain database credentials, online storage system credentials, secret keys, etc.",
+	  "remediation": "Load SECRET_KEY and database passwords from the environment instead, and change the committed ones"
 	},
 	{
+	  "id": "php-config",
+	  "severity": "medium",
+	  "tags": ["config"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A(.*)?config(\\.inc)?\\.php\\z",
 	  "caption": "PHP configuration file",
-	  "description": "Might contain credentials and keys."
+	  "description": "Might contain credentials and keys.",
+	  "remediation": "Load passwords from the environment instead, and change the committed ones"
 	},
 	{
+	  "id": "keepass-database",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "extension",
 	  "type": "match",
 	  "pattern": "kdb",
 	  "caption": "KeePass password manager database file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the database and change the passwords stored in it"
 	},
 	{
+	  "id": "1password-database",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "extension",
 	  "type": "match",
 	  "pattern": "agilekeychain",
 	  "caption": "1Password password manager database file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the database and change the passwords stored in it"
 	},
 	{
+	  "id": "apple-keychain",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "extension",
 	  "type": "match",
 	  "pattern": "keychain",
 	  "caption": "Apple Keychain database file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the database and change the passwords stored in it"
 	},
 	{
+	  "id": "gnome-keyring",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "extension",
 	  "type": "regex",
 	  "pattern": "\\Akey(store|ring)\\z",
 	  "caption": "GNOME Keyring database file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the database and change the passwords stored in it"
 	},
 	{
+	  "id": "log-file",
+	  "severity": "low",
+	  "tags": ["data"],
 	  "part": "extension",
 	  "type": "match",
 	  "pattern": "log",
 	  "caption": "Log file",
-	  "description": "Log files might contain information such as references to secret HTTP endpoints, session IDs, user information, passwords and API keys."
+	  "description": "Log files might contain information such as references to secret HTTP endpoints, session IDs, user information, passwords and API keys.",
+	  "remediation": "Remove the log and add *.log to .gitignore - logs often contain tokens and personal data"
 	},
 	{
+	  "id": "pcap-file",
+	  "severity": "medium",
+	  "tags": ["data"],
 	  "part": "extension",
 	  "type": "match",
 	  "pattern": "pcap",
 	  "caption": "Network traffic capture file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the capture - it may contain credentials and session tokens sent over the network"
 	},
 	{
+	  "id": "sql-dump",
+	  "severity": "high",
+	  "tags": ["data", "database"],
 	  "part": "extension",
 	  "type": "regex",
 	  "pattern": "\\Asql(dump)?\\z",
 	  "caption": "SQL dump file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the dump, or replace it with synthetic data"
 	},
 	{
+	  "id": "gnucash-database",
+	  "severity": "medium",
+	  "tags": ["data"],
 	  "part": "extension",
 	  "type": "match",
 	  "pattern": "gnucash",
 	  "caption": "GnuCash database file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file - it holds personal financial data"
 	},
 	{
+	  "id": "word-backup",
+	  "severity": "low",
+	  "tags": ["word"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "backup",
 	  "caption": "Contains word: backup",
-	  "description": null
+	  "description": null,
+	  "remediation": "Check the file doesn't hold data or credentials, or rename it"
 	},
 	{
+	  "id": "word-dump",
+	  "severity": "low",
+	  "tags": ["word"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "dump",
 	  "caption": "Contains word: dump",
-	  "description": null
+	  "description": null,
+	  "remediation": "Check the file doesn't hold data or credentials, or rename it"
 	},
 	{
+	  "id": "word-password",
+	  "severity": "medium",
+	  "tags": ["word"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "password",
 	  "caption": "Contains word: password",
-	  "description": null
+	  "description": null,
+	  "remediation": "Check the file doesn't hold passwords, or rename it"
 	},
 	{
+	  "id": "word-credential",
+	  "severity": "medium",
+	  "tags": ["word"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "credential",
 	  "caption": "Contains word: credential",
-	  "description": null
+	  "description": null,
+	  "remediation": "Check the file doesn't hold credentials, or rename it"
 	},
 	{
+	  "id": "word-secret",
+	  "severity": "medium",
+	  "tags": ["word"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "secret",
 	  "caption": "Contains word: secret",
-	  "description": null
+	  "description": null,
+	  "remediation": "Check the file doesn't hold secrets, or rename it"
 	},
 	{
+	  "id": "words-private-key",
+	  "severity": "medium",
+	  "tags": ["word", "key"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "private.*key",
 	  "caption": "Contains words: private, key",
-	  "description": null
+	  "description": null,
+	  "remediation": "Check the file doesn't hold a private key, or rename it"
 	},
 	{
+	  "id": "jenkins-publish-over-ssh",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "jenkins.plugins.publish_over_ssh.BapSshPublisherPlugin.xml",
 	  "caption": "Jenkins publish over SSH plugin file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file and change the SSH keys and passwords in it"
 	},
 	{
+	  "id": "jenkins-credentials",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "credentials.xml",
 	  "caption": "Potential Jenkins credentials file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file and change the credentials in it"
 	},
 	{
+	  "id": "htpasswd",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?htpasswd\\z",
 	  "caption": "Apache htpasswd file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file and change the passwords in it"
 	},
 	{
+	  "id": "netrc",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A(\\.|_)?netrc\\z",
 	  "caption": "Configuration file for auto-login process",
-	  "description": "Might contain username and password."
+	  "description": "Might contain username and password.",
+	  "remediation": "Remove the file and change the passwords in it"
 	},
 	{
+	  "id": "kde-wallet",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "extension",
 	  "type": "match",
 	  "pattern": "kwallet",
 	  "caption": "KDE Wallet Manager database file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the database and change the passwords stored in it"
 	},
 	{
+	  "id": "mediawiki-config",
+	  "severity": "medium",
+	  "tags": ["config"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "LocalSettings.php",
 	  "caption": "Potential MediaWiki configuration file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Load the database password and secret key from the environment instead, and change the committed ones"
 	},
 	{
+	  "id": "tunnelblick-config",
+	  "severity": "medium",
+	  "tags": ["config"],
 	  "part": "extension",
 	  "type": "match",
 	  "pattern": "tblk",
 	  "caption": "Tunnelblick VPN configuration file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file, and revoke and reissue any certificate or key in it"
 	},
 	{
+	  "id": "rubygems-credentials",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "path",
 	  "type": "regex",
 	  "pattern": "\\.?gem/credentials\\z",
 	  "caption": "Rubygems credentials file",
-	  "description": "Might contain API key for a rubygems.org account."
+	  "description": "Might contain API key for a rubygems.org account.",
+	  "remediation": "Remove the file and reset the API key on rubygems.org"
 	},
 	{
+	  "id": "msbuild-publish-profile",
+	  "severity": "medium",
+	  "tags": ["credentials"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A*\\.pubxml(\\.user)?\\z",
 	  "caption": "Potential MSBuild publish profile",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the profile and reset the deployment password"
 	},
 	{
+	  "id": "sequel-pro-bookmarks",
+	  "severity": "medium",
+	  "tags": ["credentials", "database"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "Favorites.plist",
 	  "caption": "Sequel Pro MySQL database manager bookmark file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file and change any saved database passwords"
 	},
 	{
+	  "id": "little-snitch-config",
+	  "severity": "low",
+	  "tags": ["config"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "configuration.user.xpl",
 	  "caption": "Little Snitch firewall configuration file",
-	  "description": "Contains traffic rules for applications"
+	  "description": "Contains traffic rules for applications",
+	  "remediation": "Remove the file - it lists the hosts and applications the machine connects to"
 	},
 	{
+	  "id": "day-one-journal",
+	  "severity": "low",
+	  "tags": ["data"],
 	  "part": "extension",
 	  "type": "match",
 	  "pattern": "dayone",
 	  "caption": "Day One journal file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the journal - it holds personal data"
 	},
 	{
+	  "id": "jrnl-journal",
+	  "severity": "low",
+	  "tags": ["data"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "journal.txt",
 	  "caption": "Potential jrnl journal file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the journal, or rename it if it isn't a jrnl journal"
 	},
 	{
+	  "id": "tugboat-config",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?tugboat\\z",
 	  "caption": "Tugboat DigitalOcean management tool configuration",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file and revoke the DigitalOcean token in it"
 	},
 	{
+	  "id": "git-credentials",
+	  "severity": "critical",
+	  "tags": ["credentials"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?git-credentials\\z",
 	  "caption": "git-credential-store helper credentials file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file and revoke the passwords and tokens in it"
 	},
 	{
+	  "id": "gitconfig",
+	  "severity": "low",
+	  "tags": ["config"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?gitconfig\\z",
 	  "caption": "Git configuration file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Check the file doesn't hold credentials or tokens, e.g. in url or http sections"
 	},
 	{
+	  "id": "chef-knife-config",
+	  "severity": "medium",
+	  "tags": ["config"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "knife.rb",
 	  "caption": "Chef Knife configuration file",
-	  "description": "Might contain references to Chef servers"
+	  "description": "Might contain references to Chef servers",
+	  "remediation": "Remove the file and change any keys or passwords in it"
 	},
 	{
+	  "id": "chef-private-key",
+	  "severity": "high",
+	  "tags": ["key"],
 	  "part": "path",
 	  "type": "regex",
 	  "pattern": "\\.?chef/(.*)\\.pem\\z",
 	  "caption": "Chef private key",
-	  "description": "Can be used to authenticate against Chef servers"
+	  "description": "Can be used to authenticate against Chef servers",
+	  "remediation": "Remove the key and generate a new key pair - the old one should be treated as compromised"
 	},
 	{
+	  "id": "proftpd-credentials",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "proftpdpasswd",
 	  "caption": "cPanel backup ProFTPd credentials file",
-	  "description": "Contains usernames and password hashes for FTP accounts"
+	  "description": "Contains usernames and password hashes for FTP accounts",
+	  "remediation": "Remove the file and change the FTP passwords in it"
 	},
 	{
+	  "id": "robomongo-config",
+	  "severity": "high",
+	  "tags": ["credentials", "database"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "robomongo.json",
 	  "caption": "Robomongo MongoDB manager configuration file",
-	  "description": "Might contain credentials for MongoDB databases"
+	  "description": "Might contain credentials for MongoDB databases",
+	  "remediation": "Remove the file and change any saved database passwords"
 	},
 	{
+	  "id": "filezilla-config",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "filezilla.xml",
 	  "caption": "FileZilla FTP configuration file",
-	  "description": "Might contain credentials for FTP servers"
+	  "description": "Might contain credentials for FTP servers",
+	  "remediation": "Remove the file and change any saved FTP passwords"
 	},
 	{
+	  "id": "filezilla-recent-servers",
+	  "severity": "medium",
+	  "tags": ["credentials"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "recentservers.xml",
 	  "caption": "FileZilla FTP recent servers file",
-	  "description": "Might contain credentials for FTP servers"
+	  "description": "Might contain credentials for FTP servers",
+	  "remediation": "Remove the file and change any saved FTP passwords"
 	},
 	{
+	  "id": "ventrilo-server-config",
+	  "severity": "medium",
+	  "tags": ["credentials"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "ventrilo_srv.ini",
 	  "caption": "Ventrilo server configuration file",
-	  "description": "Might contain passwords"
+	  "description": "Might contain passwords",
+	  "remediation": "Remove the file and change the server passwords in it"
 	},
 	{
+	  "id": "dockercfg",
+	  "severity": "high",
+	  "tags": ["credentials", "docker"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?dockercfg\\z",
 	  "caption": "Docker configuration file",
-	  "description": "Might contain credentials for public or private Docker registries"
+	  "description": "Might contain credentials for public or private Docker registries",
+	  "remediation": "Remove the file and revoke the registry credentials in it"
 	},
 	{
+	  "id": "npmrc",
+	  "severity": "high",
+	  "tags": ["credentials"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?npmrc\\z",
 	  "caption": "NPM configuration file",
-	  "description": "Might contain credentials for NPM registries"
+	  "description": "Might contain credentials for NPM registries",
+	  "remediation": "Remove the auth token from the file and revoke it in npm, then use an environment variable instead"
 	},
 	{
+	  "id": "terraform-tfvars",
+	  "severity": "high",
+	  "tags": ["credentials", "terraform"],
 	  "part": "filename",
 	  "type": "match",
 	  "pattern": "terraform.tfvars",
 	  "caption": "Terraform variable config file",
-	  "description": "Might contain credentials for terraform providers"
+	  "description": "Might contain credentials for terraform providers",
+	  "remediation": "Remove the file and pass secret variables through the environment or a secret store instead"
 	},
 	{
+	  "id": "env-file",
+	  "severity": "high",
+	  "tags": ["credentials", "config"],
 	  "part": "filename",
 	  "type": "regex",
 	  "pattern": "\\A\\.?env\\z",
 	  "caption": "Environment configuration file",
-	  "description": null
+	  "description": null,
+	  "remediation": "Remove the file, add it to .gitignore and commit an example file with placeholder values instead"
 	}
   ]
 `)
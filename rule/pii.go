@@ -0,0 +1,80 @@
+package rule
+
+// piiRulesJSON are line rules for UK personal data. They're in their own
+// "pii" ruleset, which is only run when the pii detector is enabled, as they
+// are much more likely than the "line" rules to match test data and
+// documentation.
+var piiRulesJSON = []byte(`
+[
+	{
+		"id": "pii-nino",
+		"severity": "high",
+		"tags": ["pii"],
+		"category": "nino",
+		"type": "regex",
+		"pattern": "\\b[A-CEGHJ-PR-TW-Z][A-CEGHJ-NPR-TW-Z] ?[0-9]{2} ?[0-9]{2} ?[0-9]{2} ?[A-D]\\b",
+		"caption": "Possible National Insurance number",
+		"description": null,
+		"remediation": "Replace the number with one from a reserved range, e.g. starting QQ, or with synthetic data",
+		"validator": "nino"
+	},
+	{
+		"id": "pii-nhs-number",
+		"severity": "high",
+		"tags": ["pii"],
+		"category": "nhs-number",
+		"type": "regex",
+		"pattern": "\\b[0-9]{3}[ -]?[0-9]{3}[ -]?[0-9]{4}\\b",
+		"caption": "Possible NHS number",
+		"description": null,
+		"remediation": "Replace the number with synthetic data, e.g. a number starting 999 that isn't issued",
+		"validator": "nhs-mod11"
+	},
+	{
+		"id": "pii-postcode",
+		"severity": "low",
+		"tags": ["pii"],
+		"category": "postcode",
+		"type": "regex",
+		"pattern": "\\b[A-Z]{1,2}[0-9][A-Z0-9]? ?[0-9][ABD-HJLNP-UW-Z]{2}\\b",
+		"caption": "Possible UK postcode",
+		"description": null,
+		"remediation": "Remove addresses of real people, or set a threshold for postcodes in the config"
+	},
+	{
+		"id": "pii-email",
+		"severity": "medium",
+		"tags": ["pii"],
+		"category": "email",
+		"type": "regex",
+		"pattern": "\\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\\.[A-Za-z0-9-]+)*\\.[A-Za-z]{2,}\\b",
+		"caption": "Possible email address",
+		"description": null,
+		"remediation": "Replace the address with one at example.com",
+		"validator": "email"
+	},
+	{
+		"id": "pii-date-of-birth",
+		"severity": "medium",
+		"tags": ["pii"],
+		"category": "date-of-birth",
+		"type": "regex",
+		"pattern": "(?i)\\b(?:dob|d\\.o\\.b|date[ _-]?of[ _-]?birth|birth[ _-]?date)\\b[^0-9\\n]{0,5}[0-9]{1,4}[/.-][0-9]{1,2}[/.-][0-9]{2,4}\\b",
+		"caption": "Possible date of birth",
+		"description": null,
+		"remediation": "Replace the date with synthetic data"
+	},
+	{
+		"id": "pii-phone",
+		"severity": "medium",
+		"tags": ["pii"],
+		"category": "phone",
+		"type": "regex",
+		"pattern": "(?:\\+44 ?(?:\\(0\\) ?)?|\\b0)(?:7[0-9]{3} ?[0-9]{3} ?[0-9]{3}|2[0-9] ?[0-9]{4} ?[0-9]{4}|1[0-9]{2,3} ?[0-9]{3} ?[0-9]{3,4})\\b",
+		"caption": "Possible UK phone number",
+		"description": null,
+		"remediation": "Replace the number with one reserved for drama, e.g. 07700 900000 to 07700 900999",
+		"validator": "uk-phone"
+	}
+]
+`)
@@ -4,19 +4,62 @@ package rule
 
 import (
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 )
 
 // Rule represents a rule that can be run against part of a patch or a filename
 type Rule struct {
+	ID          string         `json:"id"` // Unique across all rulesets
+	Severity    string         `json:"severity"`
+	Tags        []string       `json:"tags"`
 	Part        string         `json:"part"` // Only applicable to file types
 	Type        string         `json:"type"`
 	Pattern     string         `json:"pattern"`
 	Caption     string         `json:"caption"`
 	Description string         `json:"description"`
+	Remediation string         `json:"remediation"` // Hint on how to fix a finding
+	Validator   string         `json:"validator"`   // Name of a registered Validator to check matches with
+	Invalid     string         `json:"invalid"`     // Drop or Downgrade matches that fail validation
+	Scope       string         `json:"scope"`       // LineScope (the default) or HunkScope, for line rules
+	Category    string         `json:"category"`    // Kind of data found, e.g. "postcode", used for thresholds
 	Regex       *regexp.Regexp `json:"regex,omitempty"`
+
+	validate Validator
+}
+
+// Valid reports whether a match of the rule passes its validator. Matches of
+// rules without a validator are always valid.
+func (r Rule) Valid(match []byte) bool {
+	return r.validate == nil || r.validate(match)
 }
 
+// Rule severities, from least to most severe. Rules that don't give a
+// severity are Medium.
+const (
+	Low      = "low"
+	Medium   = "medium"
+	High     = "high"
+	Critical = "critical"
+)
+
+// Line rule scopes - what each rule's pattern is matched against
+const (
+	// LineScope matches each added line on its own
+	LineScope = "line"
+
+	// HunkScope matches runs of consecutive added lines joined by newlines,
+	// so that a pattern can span several lines
+	HunkScope = "hunk"
+)
+
+// Severities lists the valid severities in increasing order
+var Severities = []string{Low, Medium, High, Critical}
+
 var (
 	// Sets contain the available rulesets
 	Sets map[string][]Rule
@@ -31,6 +74,10 @@ func init() {
 	if err := json.Unmarshal(gitrobJSON, &gr); err != nil {
 		panic(err)
 	}
+	seen := map[string]bool{}
+	if err := compile("gitrob", gr, seen); err != nil {
+		panic(err)
+	}
 	Sets["file"] = gr
 
 	// Compile the patch line level rules
@@ -38,14 +85,243 @@ func init() {
 	if err := json.Unmarshal(lineRulesJSON, &pr); err != nil {
 		panic(err)
 	}
+	if err := compile("line", pr, seen); err != nil {
+		panic(err)
+	}
 	Sets["line"] = pr
 
-	// For all regex types, precompile the regex
-	for _, set := range []string{"file", "line"} {
-		for i := range Sets[set] {
-			if Sets[set][i].Type == "regex" {
-				Sets[set][i].Regex = regexp.MustCompile(Sets[set][i].Pattern)
+	// Compile the personal data rules
+	var pii []Rule
+	if err := json.Unmarshal(piiRulesJSON, &pii); err != nil {
+		panic(err)
+	}
+	if err := compile("pii", pii, seen); err != nil {
+		panic(err)
+	}
+	Sets["pii"] = pii
+}
+
+// Error describes a problem with a single rule loaded from a rule file
+type Error struct {
+	// File (or embedded ruleset name) the rule came from
+	Source string
+
+	// Position of the rule within the source, starting from zero
+	Index int
+
+	// Pattern of the offending rule
+	Pattern string
+
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: rule %d (%q): %v", e.Source, e.Index, e.Pattern, e.Err)
+}
+
+// Errors is a collection of problems found whilst loading one or more rule
+// files
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Load reads a JSON file of rules, in the same schema as the embedded rulesets,
+// and merges them into Sets. Rules that specify a `part` are filename rules and
+// are added to the "file" set, all others are added to the "line" set.
+//
+// If any rule in the file is invalid then none of the file's rules are merged
+// and an Errors listing each bad rule is returned.
+func Load(path string) error {
+	return LoadInto(Sets, path)
+}
+
+// LoadDir loads every `.json` file found directly within dir using Load. A bad
+// file doesn't prevent the others from being loaded - all problems found are
+// returned together.
+func LoadDir(dir string) error {
+	return LoadDirInto(Sets, dir)
+}
+
+// LoadPath loads path with LoadDir if it is a directory and with Load
+// otherwise
+func LoadPath(path string) error {
+	return LoadPathInto(Sets, path)
+}
+
+// LoadInto is Load, but merges the rules into sets rather than Sets
+func LoadInto(sets map[string][]Rule, path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	seen := map[string]bool{}
+	for _, set := range sets {
+		for _, r := range set {
+			seen[r.ID] = true
+		}
+	}
+	if err := compile(path, rules, seen); err != nil {
+		return err
+	}
+
+	for _, r := range rules {
+		if r.Part != "" {
+			sets["file"] = append(sets["file"], r)
+		} else {
+			sets["line"] = append(sets["line"], r)
+		}
+	}
+	return nil
+}
+
+// LoadDirInto is LoadDir, but merges the rules into sets rather than Sets
+func LoadDirInto(sets map[string][]Rule, dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	var errs Errors
+	for _, path := range paths {
+		if err := LoadInto(sets, path); err != nil {
+			if e, ok := err.(Errors); ok {
+				errs = append(errs, e...)
+			} else {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// LoadPathInto is LoadPath, but merges the rules into sets rather than Sets
+func LoadPathInto(sets map[string][]Rule, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return LoadDirInto(sets, path)
+	}
+	return LoadInto(sets, path)
+}
+
+// Copy returns a copy of sets, e.g. of Sets, that can be changed without
+// affecting the original
+func Copy(sets map[string][]Rule) map[string][]Rule {
+	out := make(map[string][]Rule, len(sets))
+	for name, rules := range sets {
+		out[name] = append([]Rule(nil), rules...)
+	}
+	return out
+}
+
+// compile validates each rule and precompiles the regex for all regex types.
+// IDs are checked against, and added to, seen. Returns an Errors detailing
+// every invalid rule.
+func compile(source string, rules []Rule, seen map[string]bool) error {
+	var errs Errors
+	for i := range rules {
+		r := &rules[i]
+
+		fail := func(err error) {
+			errs = append(errs, &Error{Source: source, Index: i, Pattern: r.Pattern, Err: err})
+		}
+
+		switch {
+		case r.ID == "":
+			fail(fmt.Errorf("missing id"))
+			continue
+		case seen[r.ID]:
+			fail(fmt.Errorf("duplicate id %q", r.ID))
+			continue
+		}
+		seen[r.ID] = true
+
+		if r.Severity == "" {
+			r.Severity = Medium
+		}
+		if SeverityLevel(r.Severity) < 0 {
+			fail(fmt.Errorf("unknown severity %q", r.Severity))
+			continue
+		}
+
+		switch r.Part {
+		case "", "extension", "path", "filename":
+		default:
+			fail(fmt.Errorf("unknown part %q", r.Part))
+			continue
+		}
+
+		if r.Validator != "" {
+			v, ok := LookupValidator(r.Validator)
+			if !ok {
+				fail(fmt.Errorf("unknown validator %q", r.Validator))
+				continue
+			}
+			r.validate = v
+		}
+		switch r.Scope {
+		case "", LineScope:
+		case HunkScope:
+			if r.Part != "" || r.Type != "regex" {
+				fail(fmt.Errorf("hunk scope rules must be regex line rules"))
+				continue
 			}
+		default:
+			fail(fmt.Errorf("unknown scope %q", r.Scope))
+			continue
+		}
+		switch r.Invalid {
+		case "", Drop, Downgrade:
+		default:
+			fail(fmt.Errorf("unknown invalid action %q", r.Invalid))
+			continue
+		}
+
+		switch r.Type {
+		case "regex":
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				fail(err)
+				continue
+			}
+			r.Regex = re
+		case "match":
+			if r.Part == "" {
+				fail(fmt.Errorf("match rules must specify a part"))
+			}
+		default:
+			fail(fmt.Errorf("unknown type %q", r.Type))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// SeverityLevel returns the position of severity in Severities, so that they
+// can be compared, or -1 if it's not a valid severity
+func SeverityLevel(severity string) int {
+	for i, s := range Severities {
+		if s == severity {
+			return i
 		}
 	}
+	return -1
 }
@@ -1,6 +1,9 @@
 package rule_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/ONSdigital/git-diff-check/rule"
@@ -14,3 +17,182 @@ func TestInitRules(t *testing.T) {
 		t.Error("Failed to initialise rulesets")
 	}
 }
+
+func TestInitRuleIDs(t *testing.T) {
+	// Built in rules must all have unique IDs and valid severities, which is
+	// checked during init(). Make sure nothing slipped through.
+	seen := map[string]bool{}
+	for _, set := range rule.Sets {
+		for _, r := range set {
+			if r.ID == "" || seen[r.ID] {
+				t.Errorf("Missing or duplicate rule ID %q (%s)", r.ID, r.Caption)
+			}
+			seen[r.ID] = true
+			if rule.SeverityLevel(r.Severity) < 0 {
+				t.Errorf("Invalid severity %q for %s", r.Severity, r.ID)
+			}
+		}
+	}
+}
+
+func TestInitRuleRemediation(t *testing.T) {
+	// Every built in rule should say how to fix what it finds
+	for name, set := range rule.Sets {
+		for _, r := range set {
+			if r.Remediation == "" {
+				t.Errorf("Missing remediation for %s in the %s set", r.ID, name)
+			}
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	defer restoreSets(rule.Sets)()
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := writeFile(t, dir, "custom.json", `[
+		{"id": "internal-secrets-file", "part": "filename", "type": "match", "pattern": "secrets.txt", "caption": "Internal secrets file"},
+		{"id": "internal-token", "severity": "high", "type": "regex", "pattern": "INT-[0-9]{8}", "caption": "Internal token"}
+	]`)
+
+	files, lines := len(rule.Sets["file"]), len(rule.Sets["line"])
+
+	if err := rule.Load(path); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if got := len(rule.Sets["file"]); got != files+1 {
+		t.Errorf("Expected %d file rules, got %d", files+1, got)
+	}
+	if got := len(rule.Sets["line"]); got != lines+1 {
+		t.Errorf("Expected %d line rules, got %d", lines+1, got)
+	}
+
+	last := rule.Sets["line"][len(rule.Sets["line"])-1]
+	if last.Regex == nil || !last.Regex.MatchString("token=INT-12345678") {
+		t.Error("Expected loaded line rule to be compiled")
+	}
+	if last.Severity != rule.High {
+		t.Errorf("Expected severity %s, got %s", rule.High, last.Severity)
+	}
+
+	file := rule.Sets["file"][len(rule.Sets["file"])-1]
+	if file.Severity != rule.Medium {
+		t.Errorf("Expected default severity %s, got %s", rule.Medium, file.Severity)
+	}
+}
+
+func TestLoadInto(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := writeFile(t, dir, "custom.json", `[
+		{"id": "internal-token", "type": "regex", "pattern": "INT-[0-9]{8}", "caption": "Internal token"}
+	]`)
+
+	lines := len(rule.Sets["line"])
+	sets := rule.Copy(rule.Sets)
+
+	if err := rule.LoadPathInto(sets, path); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if got := len(sets["line"]); got != lines+1 {
+		t.Errorf("Expected %d line rules, got %d", lines+1, got)
+	}
+	if got := len(rule.Sets["line"]); got != lines {
+		t.Errorf("Expected Sets to be unchanged with %d line rules, got %d", lines, got)
+	}
+
+	// IDs are checked against the rules already in the given sets
+	if err := rule.LoadInto(sets, path); err == nil {
+		t.Error("Expected an error loading the same rules twice")
+	}
+	if err := rule.LoadInto(rule.Copy(rule.Sets), path); err != nil {
+		t.Errorf("Expected no error loading into a fresh copy, but got: %v", err)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	defer restoreSets(rule.Sets)()
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := writeFile(t, dir, "bad.json", `[
+		{"id": "fine", "type": "regex", "pattern": "ok[0-9]+", "caption": "Fine"},
+		{"id": "bad-regex", "type": "regex", "pattern": "broken[", "caption": "Bad regex"},
+		{"id": "bad-type", "type": "glob", "pattern": "*", "caption": "Bad type"},
+		{"type": "regex", "pattern": "no-id", "caption": "Missing ID"},
+		{"id": "fine", "type": "regex", "pattern": "dupe", "caption": "Duplicate ID"},
+		{"id": "aws-access-key", "type": "regex", "pattern": "dupe", "caption": "Duplicate built in ID"},
+		{"id": "bad-severity", "severity": "urgent", "type": "regex", "pattern": "x", "caption": "Bad severity"},
+		{"id": "bad-validator", "validator": "luhn", "type": "regex", "pattern": "x", "caption": "Bad validator"},
+		{"id": "bad-invalid", "invalid": "ignore", "type": "regex", "pattern": "x", "caption": "Bad invalid action"},
+		{"id": "bad-scope", "scope": "file", "type": "regex", "pattern": "x", "caption": "Bad scope"},
+		{"id": "bad-hunk", "scope": "hunk", "part": "filename", "type": "match", "pattern": "x", "caption": "Hunk scope file rule"}
+	]`)
+
+	lines := len(rule.Sets["line"])
+
+	err := rule.Load(path)
+	errs, ok := err.(rule.Errors)
+	if !ok {
+		t.Fatalf("Expected rule.Errors, but got: %v", err)
+	}
+	if len(errs) != 10 {
+		t.Fatalf("Expected 10 errors, got %d: %v", len(errs), errs)
+	}
+	if e := errs[0].(*rule.Error); e.Source != path || e.Index != 1 {
+		t.Errorf("Expected error for rule 1 in %s, got rule %d in %s", path, e.Index, e.Source)
+	}
+	for i, err := range errs[1:] {
+		if e := err.(*rule.Error); e.Index != i+2 {
+			t.Errorf("Expected error for rule %d, got rule %d", i+2, e.Index)
+		}
+	}
+	if got := len(rule.Sets["line"]); got != lines {
+		t.Errorf("Expected no rules to be merged from a bad file, got %d new", got-lines)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	defer restoreSets(rule.Sets)()
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writeFile(t, dir, "a.json", `[{"id": "a", "type": "regex", "pattern": "aaa", "caption": "A"}]`)
+	writeFile(t, dir, "b.json", `[{"id": "b", "type": "regex", "pattern": "(", "caption": "B"}]`)
+	writeFile(t, dir, "c.json", `[{"id": "c", "type": "regex", "pattern": "ccc", "caption": "C"}]`)
+	writeFile(t, dir, "notes.txt", `not a rule file`)
+
+	lines := len(rule.Sets["line"])
+
+	err := rule.LoadDir(dir)
+	if errs, ok := err.(rule.Errors); !ok || len(errs) != 1 {
+		t.Errorf("Expected a single error for b.json, got: %v", err)
+	}
+	if got := len(rule.Sets["line"]); got != lines+2 {
+		t.Errorf("Expected 2 rules to be merged, got %d", got-lines)
+	}
+}
+
+// restoreSets returns a func that puts back a copy of the given rulesets so
+// tests loading rules don't leak into each other
+func restoreSets(sets map[string][]rule.Rule) func() {
+	saved := rule.Copy(sets)
+	return func() { rule.Sets = saved }
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "rule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,197 @@
+package rule
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"sync"
+)
+
+// Validator checks a regex match in more detail than a pattern can, e.g. by
+// verifying a checksum, and reports whether it's likely to be real
+type Validator func(match []byte) bool
+
+// What to do with matches that fail a rule's validator - see Rule.Invalid
+const (
+	// Drop doesn't report the match at all. This is the default.
+	Drop = "drop"
+
+	// Downgrade reports the match with Low severity
+	Downgrade = "downgrade"
+)
+
+var (
+	validatorsMu sync.RWMutex
+	validators   = map[string]Validator{
+		"github-checksum": ValidateCRC32Token,
+		"npm-checksum":    ValidateCRC32Token,
+		"jwt":             ValidateJWT,
+		"nino":            ValidateNINO,
+		"nhs-mod11":       ValidateNHSNumber,
+		"email":           ValidateEmail,
+		"uk-phone":        ValidatePhoneNumber,
+	}
+)
+
+// RegisterValidator makes a validator available to rules by name. It must be
+// called before any rules using it are loaded, e.g. from an init function.
+// Panics if a validator with the same name is already registered.
+func RegisterValidator(name string, v Validator) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+
+	if _, dup := validators[name]; dup {
+		panic(fmt.Sprintf("rule: validator %q registered twice", name))
+	}
+	validators[name] = v
+}
+
+// LookupValidator returns the registered validator with the given name
+func LookupValidator(name string) (Validator, bool) {
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+
+	v, ok := validators[name]
+	return v, ok
+}
+
+// base62 is the alphabet used to encode token checksums
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// ValidateCRC32Token checks tokens in the format used by GitHub and npm - a
+// prefix ending in `_`, 30 random characters, then the CRC32 of the random
+// characters as 6 base62 digits
+func ValidateCRC32Token(match []byte) bool {
+	i := bytes.LastIndexByte(match, '_')
+	if i < 0 || len(match)-i-1 != 36 {
+		return false
+	}
+	random, checksum := match[i+1:len(match)-6], match[len(match)-6:]
+
+	n := crc32.ChecksumIEEE(random)
+	encoded := make([]byte, 6)
+	for j := len(encoded) - 1; j >= 0; j-- {
+		encoded[j] = base62[n%62]
+		n /= 62
+	}
+	return n == 0 && bytes.Equal(encoded, checksum)
+}
+
+// ValidateJWT checks that the first part of a JSON Web Token is a JSON header
+// naming a signing algorithm
+func ValidateJWT(match []byte) bool {
+	i := bytes.IndexByte(match, '.')
+	if i < 0 {
+		return false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(string(match[:i]))
+	if err != nil {
+		return false
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	return json.Unmarshal(b, &header) == nil && header.Alg != ""
+}
+
+// ninoPrefixes are the letter pairs that are never used at the start of a
+// National Insurance number
+var ninoPrefixes = []string{"BG", "GB", "KN", "NK", "NT", "TN", "ZZ"}
+
+// ValidateNINO checks that a National Insurance number doesn't start with a
+// prefix that's never issued
+func ValidateNINO(match []byte) bool {
+	for _, p := range ninoPrefixes {
+		if bytes.HasPrefix(match, []byte(p)) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateNHSNumber checks the mod 11 check digit of an NHS number. Numbers
+// starting 999, which are reserved for testing, and numbers of a single
+// repeated digit aren't treated as valid.
+func ValidateNHSNumber(match []byte) bool {
+	var digits []int
+	for _, b := range match {
+		if b >= '0' && b <= '9' {
+			digits = append(digits, int(b-'0'))
+		}
+	}
+	if len(digits) != 10 {
+		return false
+	}
+	if digits[0] == 9 && digits[1] == 9 && digits[2] == 9 {
+		return false
+	}
+	same := true
+	for _, d := range digits[1:] {
+		same = same && d == digits[0]
+	}
+	if same {
+		return false
+	}
+
+	sum := 0
+	for i, d := range digits[:9] {
+		sum += d * (10 - i)
+	}
+	check := 11 - sum%11
+	if check == 11 {
+		check = 0
+	}
+	return check != 10 && check == digits[9]
+}
+
+// reservedDomains are the domains, and top level domains, reserved for
+// documentation and testing
+var reservedDomains = []string{"example.com", "example.org", "example.net", "example", "test", "invalid", "localhost"}
+
+// ValidateEmail checks that an email address isn't at a domain reserved for
+// documentation and testing
+func ValidateEmail(match []byte) bool {
+	i := bytes.LastIndexByte(match, '@')
+	if i < 0 {
+		return false
+	}
+	domain := strings.ToLower(string(match[i+1:]))
+	for _, d := range reservedDomains {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return false
+		}
+	}
+	return true
+}
+
+// dramaNumbers are the prefixes, in national format, of the phone number
+// ranges Ofcom reserves for use in drama
+var dramaNumbers = []string{
+	"07700900",
+	"02079460", "01914980", "02896496", "02920180", "01632960",
+	"01134960", "01144960", "01154960", "01164960", "01174960", "01184960",
+	"01214960", "01314960", "01414960", "01514960", "01614960",
+}
+
+// ValidatePhoneNumber checks that a UK phone number isn't in one of the ranges
+// reserved for drama, e.g. 07700 900000 to 07700 900999
+func ValidatePhoneNumber(match []byte) bool {
+	var digits []byte
+	for _, b := range match {
+		if b >= '0' && b <= '9' {
+			digits = append(digits, b)
+		}
+	}
+	if bytes.HasPrefix(match, []byte("+44")) {
+		digits = append([]byte("0"), bytes.TrimPrefix(digits[2:], []byte("0"))...)
+	}
+	for _, p := range dramaNumbers {
+		if bytes.HasPrefix(digits, []byte(p)) {
+			return false
+		}
+	}
+	return true
+}